@@ -0,0 +1,266 @@
+package tri
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ConfigFileName is the name of the configuration file inside the data directory of an application.
+const ConfigFileName = "config"
+
+// builtinTriggers are the Triggers that every validated Tri has at its root, and builtinCommands are the Commands it has in its Commands. They are set up in init so their handlers may refer to anything in the package without creating an initialisation cycle.
+var (
+	builtinTriggers []Trigger
+	builtinCommands []Command
+)
+
+func init() {
+	builtinCommands = []Command{
+		{"help",
+			Brief{"show help for the application, a command, or a variable"},
+			Usage{"help [command|name|command/name]"},
+			Help{"Without a topic, shows the version and description of the application and lists its commands and options. Given the name of a command, shows its options, grouped by their Group. Given the name of a variable or trigger, shows its help text, default value and how it appears in the configuration file. Items belonging to a command can be named as command/name."},
+			runHelp,
+		},
+	}
+	builtinTriggers = []Trigger{
+		{"init",
+			Short{'I'},
+			Brief{"delete the configuration file and exit"},
+			Help{"Deletes the configuration file, then exits. Future runs will then start from the defaults."},
+			Terminates{},
+			runInit,
+		},
+		{"save",
+			Short{'S'},
+			Brief{"save the configuration after parsing config and CLI args"},
+			Help{"At the end of a successful parse of the configuration file and CLI args, the new state is written to the configuration file. Only values that differ from the defaults are stored."},
+			runSave,
+		},
+		{"defaults",
+			Brief{"print every name with its default value and exit"},
+			Help{"Prints the entire set of names as they would appear in the configuration file, with their default values. It is not necessary to put these lines in the configuration file, they will be removed when it is rewritten."},
+			Terminates{},
+			runDefaults,
+		},
+	}
+}
+
+// addBuiltins appends the built-in Triggers to the root of a Tri and the built-in Commands to its Commands, unless they are already there, and if the Tri has no datadir Var of its own, a datadir Var defaulting to a folder named after the Tri in the user's home directory.
+func (r *Tri) addBuiltins() {
+	for _, b := range builtinTriggers {
+		found := false
+		for _, x := range *r {
+			if t, ok := x.(Trigger); ok && sameNode(t, b) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*r = append(*r, b)
+		}
+	}
+	for _, b := range builtinCommands {
+		found := false
+		for i, x := range *r {
+			if c, ok := x.(Commands); ok {
+				found = true
+				if !r.hasCommand(b) {
+					(*r)[i] = append(c, b)
+				}
+			}
+		}
+		if !found {
+			*r = append(*r, Commands{b})
+		}
+	}
+	if r.dataDirVar() == nil {
+		*r = append(*r, Var{dataDir,
+			Short{'D'},
+			Brief{"directory where the configuration and data are kept"},
+			Help{"The data directory is created if it does not exist, and the configuration file is kept inside it. A leading ~ is replaced by the home directory and environment variables in the form $NAME or ${NAME} are expanded."},
+			Default{defaultDataDir(nameOf(*r))},
+			Slot{new(string)},
+		})
+	}
+}
+
+// defaultDataDir returns the default data directory for an application, a dot folder in the home directory, or on Windows, a folder in the local application data directory.
+func defaultDataDir(appname string) string {
+	appname = strings.ToLower(appname)
+	if runtime.GOOS == "windows" {
+		return filepath.Join("${LOCALAPPDATA}", appname)
+	}
+	return "~/." + appname
+}
+
+// hasCommand returns true if the Command is in the Tri's Commands.
+func (r *Tri) hasCommand(c Command) bool {
+	for _, x := range *r {
+		if cc, ok := x.(Commands); ok {
+			for _, y := range cc {
+				if sameNode(y, c) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// hasItem returns true if the Var or Trigger is at the root of the Tri.
+func (r *Tri) hasItem(node []interface{}) bool {
+	for _, x := range *r {
+		switch y := x.(type) {
+		case Var:
+			if sameNode(y, node) {
+				return true
+			}
+		case Trigger:
+			if sameNode(y, node) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isBuiltin returns true if a Tri node is one of the built-in items rather than one declared by the application.
+func isBuiltin(node []interface{}) bool {
+	for _, b := range builtinTriggers {
+		if sameNode(node, b) {
+			return true
+		}
+	}
+	for _, b := range builtinCommands {
+		if sameNode(node, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReserved returns an error if a Var or Trigger declared by the application uses the name of a built-in Trigger, or a Command uses the name of a built-in Command.
+func checkReserved(node interface{}) error {
+	var n []interface{}
+	var reserved []string
+	switch x := node.(type) {
+	case Var:
+		n = x
+	case Trigger:
+		n = x
+	case Command:
+		n = x
+		for _, b := range builtinCommands {
+			reserved = append(reserved, nameOf(b))
+		}
+	}
+	if len(reserved) == 0 {
+		for _, b := range builtinTriggers {
+			reserved = append(reserved, nameOf(b))
+		}
+	}
+	if isBuiltin(n) {
+		return nil
+	}
+	for _, b := range reserved {
+		if strings.EqualFold(nameOf(n), b) {
+			return fmt.Errorf("name '%s' is reserved for a built-in item", nameOf(n))
+		}
+	}
+	return nil
+}
+
+// dataDir is the name of the Var at the root of a Tri that holds the path of the data directory.
+const dataDir = "datadir"
+
+// dataDirVar returns the datadir Var at the root of the Tri, or nil if there is none.
+func (r *Tri) dataDirVar() Var {
+	if v, ok := itemNamed(*r, dataDir).(Var); ok {
+		return v
+	}
+	return nil
+}
+
+// DataDir returns the path of the data directory of the application, as currently set in the datadir Var, or its default if it is empty, with a leading ~ replaced by the home directory of the user and environment variables expanded.
+func (r *Tri) DataDir() string {
+	v := r.dataDirVar()
+	s, _ := slotValue(v).(string)
+	if s == "" {
+		s, _ = defaultValue(v).(string)
+	}
+	return ExpandPath(s)
+}
+
+// MakeDataDir places the expanded path of the data directory back into the datadir Var and creates the directory, readable only by the user, if it does not exist. It must be run after the datadir is set from the CLI args and before the configuration file is loaded.
+func (r *Tri) MakeDataDir() (string, error) {
+	v := r.dataDirVar()
+	if v == nil {
+		return "", errors.New("Tri has no datadir Var, it must be validated first")
+	}
+	path := r.DataDir()
+	if path == "" {
+		return "", errors.New("data directory path is empty")
+	}
+	if e := ParseVar(&v, path); e != nil {
+		return "", e
+	}
+	if e := os.MkdirAll(path, 0700); e != nil {
+		return "", e
+	}
+	return path, nil
+}
+
+// ExpandPath replaces a leading ~ in a path with the home directory of the user and expands environment variables in the form $NAME or ${NAME}.
+func ExpandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		if home, e := os.UserHomeDir(); e == nil {
+			path = home + path[1:]
+		}
+	}
+	path = os.ExpandEnv(path)
+	if path == "" {
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
+// ConfigFile returns the path of the configuration file of the application, inside its data directory.
+func (r *Tri) ConfigFile() string {
+	return filepath.Join(r.DataDir(), ConfigFileName)
+}
+
+// runInit is the handler of the built-in init Trigger.
+func runInit(t *Tri) int {
+	if e := os.Remove(t.ConfigFile()); e != nil && !os.IsNotExist(e) {
+		fmt.Fprintln(os.Stderr, e)
+		return 1
+	}
+	return 0
+}
+
+// runSave is the handler of the built-in save Trigger. DefaultOn Triggers named in the invocation are stored as disabled.
+func runSave(t *Tri) int {
+	var triggers []Trigger
+	if inv := t.Invocation(); inv != nil {
+		triggers = inv.Triggers
+	}
+	if e := t.SaveConfig(t.ConfigFile(), triggers); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		return 1
+	}
+	return 0
+}
+
+// runDefaults is the handler of the built-in defaults Trigger.
+func runDefaults(t *Tri) int {
+	if e := t.WriteDefaults(os.Stdout); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,198 @@
+package tri
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltins(t *testing.T) {
+	var port int
+	var peers []string
+	tb := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"port", Brief{"brief"}, Default{11048}, Slot{&port}},
+		Var{"peers", Brief{"brief"}, Default{[]string{"a", "b"}}, Slot{&peers}},
+		Trigger{"wallet", Brief{"brief"}, DefaultOn{}, MakeTestHandler()},
+		Commands{
+			{"ctl", Brief{"brief"}, MakeTestHandler()},
+		},
+	}
+	if e := tb.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+
+	// built-in Triggers are added, only once
+	if e := tb.Validate(); e != nil {
+		t.Fatal("validated Tri is no longer valid:", e)
+	}
+	count := 0
+	for _, x := range tb {
+		if y, ok := x.(Trigger); ok && isBuiltin(y) {
+			count++
+		}
+	}
+	if count != len(builtinTriggers) {
+		t.Errorf("expected %d built-in Triggers, found %d", len(builtinTriggers), count)
+	}
+	for _, x := range []string{"init", "save", "defaults", "-I", "-S"} {
+		inv, e := tb.Parse([]string{"-" + strings.TrimPrefix(x, "-")})
+		if e != nil || len(inv.Triggers) != 1 || !isBuiltin(inv.Triggers[0]) {
+			t.Error("built-in Trigger not recognised by parser:", x, e)
+		}
+	}
+
+	// reserved names may not be declared
+	for _, x := range []Tri{
+		{"appname", Brief{"brief"}, Version{0, 1, 1},
+			Trigger{"init", Brief{"brief"}, MakeTestHandler()}},
+		{"appname", Brief{"brief"}, Version{0, 1, 1},
+			Var{"Save", Brief{"brief"}, Slot{&port}}},
+		{"appname", Brief{"brief"}, Version{0, 1, 1}, Commands{
+			{"ctl", Brief{"brief"}, MakeTestHandler(),
+				Trigger{"defaults", Brief{"brief"}, MakeTestHandler()}},
+		}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Error("validator accepted a reserved name:", x[len(x)-1])
+		}
+	}
+
+	// defaults prints every Var with its default value
+	var b strings.Builder
+	if _, e := LoadAllDefaults(&tb); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	port = 1
+	if e := tb.WriteDefaults(&b); e != nil {
+		t.Fatal("defaults writer failed:", e)
+	}
+	if b.String() != "port 11048\npeers\n\t\ta\n\t\tb\nctl\n" {
+		t.Errorf("defaults writer output incorrect:\n%s", b.String())
+	}
+
+	// save and init write and remove the configuration file in the data directory
+	home, e := ioutil.TempDir("", "tri")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	if e = os.MkdirAll(tb.DataDir(), 0700); e != nil {
+		t.Fatal(e)
+	}
+	if _, e = tb.Parse([]string{"--wallet", "--save"}); e != nil {
+		t.Fatal(e)
+	}
+	if runSave(&tb) != 0 {
+		t.Fatal("save Trigger failed")
+	}
+	conf, e := ioutil.ReadFile(tb.ConfigFile())
+	if e != nil || string(conf) != "port 1\nwallet\nctl\n" {
+		t.Errorf("save Trigger wrote incorrect configuration: %q %v", conf, e)
+	}
+	if runInit(&tb) != 0 {
+		t.Fatal("init Trigger failed")
+	}
+	if _, e = os.Stat(tb.ConfigFile()); !os.IsNotExist(e) {
+		t.Error("init Trigger did not remove the configuration file")
+	}
+	if runInit(&tb) != 0 {
+		t.Error("init Trigger failed when there is no configuration file")
+	}
+}
+
+func TestDataDir(t *testing.T) {
+	home, e := ioutil.TempDir("", "tri")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	td := Tri{"AppName", Brief{"brief"}, Version{0, 1, 1},
+		Commands{{"ctl", Brief{"brief"}, MakeTestHandler()}},
+	}
+	if e = td.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	dd := td.dataDirVar()
+	if dd == nil {
+		t.Fatal("datadir Var was not added")
+	}
+	if e = td.Validate(); e != nil {
+		t.Fatal("validated Tri is no longer valid:", e)
+	}
+	if defaultValue(dd) != "~/.appname" {
+		t.Error("datadir default is not a dot folder named after the Tri:", defaultValue(dd))
+	}
+	if _, e := LoadAllDefaults(&td); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+
+	// the default is expanded and created
+	path, e := td.MakeDataDir()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if path != filepath.Join(home, ".appname") || slotValue(dd) != path {
+		t.Error("data directory not expanded into the Slot:", path)
+	}
+	if fi, e := os.Stat(path); e != nil || !fi.IsDir() || fi.Mode().Perm() != 0700 {
+		t.Error("data directory not created with safe permissions", e)
+	}
+	if td.ConfigFile() != filepath.Join(path, ConfigFileName) {
+		t.Error("configuration file is not inside the data directory")
+	}
+
+	// set by Short on the CLI, with environment variables
+	os.Setenv("TRITESTDIR", "sub")
+	if _, e = td.Parse([]string{"-D", "~/$TRITESTDIR/${TRITESTDIR}"}); e != nil {
+		t.Fatal(e)
+	}
+	if path, e = td.MakeDataDir(); e != nil || path != filepath.Join(home, "sub", "sub") {
+		t.Error("data directory from CLI not expanded", path, e)
+	}
+
+	// never written to or read from the configuration
+	var b strings.Builder
+	if e = td.WriteConfig(&b, nil); e != nil || b.String() != "ctl\n" {
+		t.Errorf("datadir written in configuration: %q %v", b.String(), e)
+	}
+	if _, e = td.ReadConfig(strings.NewReader("datadir /tmp")); e == nil {
+		t.Error("reader accepted datadir in configuration")
+	}
+
+	// a declared datadir is used instead, and must be a string
+	var mydir string
+	var notstring int
+	tm := Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"datadir", Brief{"brief"}, Default{"/my/dir"}, Slot{&mydir}},
+	}
+	if e = tm.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	vars := 0
+	for _, x := range tm {
+		if _, ok := x.(Var); ok {
+			vars++
+		}
+	}
+	if !sameNode(tm.dataDirVar(), tm[3].(Var)) || vars != 1 {
+		t.Error("datadir Var added when one was declared")
+	}
+	if tm.DataDir() != "/my/dir" {
+		t.Error("declared datadir Var not used")
+	}
+	tm = Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"datadir", Brief{"brief"}, Slot{&notstring}},
+	}
+	if e = tm.Validate(); e == nil {
+		t.Error("validator accepted datadir Var that is not a string")
+	}
+}
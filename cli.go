@@ -0,0 +1,513 @@
+package tri
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Invocation is the result of parsing the CLI args of an application against its Tri declaration. It records the Command that was selected (the DefaultCommand if none was named, or nil if there is none), along with the Commands it is nested in, the Triggers that were named, in the order they were found, and the positional operands that were not consumed as names or values.
+type Invocation struct {
+	Command Command
+	// Path is the selected Command and the Commands it is nested in, outermost first, the last is the same as Command.
+	Path     []Command
+	Triggers []Trigger
+	Args     []string
+	values   []assignment
+	// positions are the numbers of the CLI args the Args were found in, counting from 1
+	positions []int
+	// assigned are the Vars given a value in the configuration file or the CLI args, which CheckRequired accepts whatever the value
+	assigned []Var
+}
+
+// assignment is a value found for a Var or Arg in the CLI args that is yet to be placed into its Slot. The path is the name of the Var prefixed by the names of the Commands it belongs to, if any, as in commandname/varname. The value is a string, or for a Variadic Arg, a []string. The value of an Arg replaces the list in a []string Slot rather than adding to it.
+type assignment struct {
+	v     Var
+	path  string
+	value interface{}
+	index int
+	arg   bool
+}
+
+// Parse walks the CLI args (without the executable name, ie. os.Args[1:]), locates each name in the Tri, and places the converted values given for Vars into every pointer in their Slot. The resulting Invocation is returned and also kept for the Tri, where it can be found with the Invocation method.
+//
+// Names may be prefixed by one or two dashes and may be either the full name, which is case insensitive, or the Short rune. The value for a Var can be given either as --name=value or --name value, except for bool Vars, which are set to true by their name alone and to false by --noname, or either with --name=true or --name=false. The first bare word must be the name or Short of a Command, which selects it, after which the Vars and Triggers of the Command are recognised as well as those at the root of the Tri. If the Tri has a DefaultCommand, it is selected when no Command is named, its Vars and Triggers are recognised until one is, and if it declares Args, a first bare word that is not the name of a Command is its first operand. It is an error to name a different Command after using a name only found in the DefaultCommand. If the Command has Commands of its own, the next bare word must name one of them, and so on to any depth, the Vars and Triggers of every Command in the path being recognised, those of the innermost first. Further bare words are positional operands. A bare -- ends the scanning of names, everything after it is a positional operand.
+//
+// If the selected Command declares Args, the operands are placed into their Slots in the order the Args are declared, it is an error if a required Arg has no operand or if there are more operands than Args, unless the last is Variadic.
+func (r *Tri) Parse(args []string) (*Invocation, error) {
+	inv, e := r.scan(args)
+	if e != nil {
+		return nil, e
+	}
+	if e = inv.apply(); e != nil {
+		return nil, e
+	}
+	r.record(inv)
+	return inv, nil
+}
+
+// invocations are the results of the last parse of CLI args for each Tri, kept outside the declaration so that running it does not change it. They are found by the pointer the Tri was parsed through, which is the one its handlers are passed.
+var invocations = struct {
+	sync.Mutex
+	m map[*Tri]*Invocation
+}{m: make(map[*Tri]*Invocation)}
+
+// Invocation returns the result of the last parse of CLI args for the Tri, which is kept so that handlers, which are passed the root Tri, can find out what was invoked. It returns nil if the Tri has not parsed any args.
+func (r *Tri) Invocation() *Invocation {
+	invocations.Lock()
+	defer invocations.Unlock()
+	return invocations.m[r]
+}
+
+// record keeps an Invocation for the Tri, replacing any previous one.
+func (r *Tri) record(inv *Invocation) {
+	invocations.Lock()
+	defer invocations.Unlock()
+	invocations.m[r] = inv
+}
+
+// scan resolves the names in the CLI args against the Tri and collects the values for Vars without placing them into their Slots.
+func (r *Tri) scan(args []string) (*Invocation, error) {
+	inv := new(Invocation)
+	operand := func(i int) {
+		inv.Args = append(inv.Args, args[i])
+		inv.positions = append(inv.positions, i+1)
+	}
+	def := r.defaultCommand()
+	// scope returns the Commands whose names are recognised, those selected, or until one is, the DefaultCommand
+	scope := func() []Command {
+		if inv.Command == nil && def != nil {
+			return []Command{def}
+		}
+		return inv.Path
+	}
+	// implied is the first argument found only in the DefaultCommand before a Command was named, and its number
+	var implied string
+	var impliedAt int
+scan:
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			for i++; i < len(args); i++ {
+				operand(i)
+			}
+			break scan
+		case len(a) > 1 && a[0] == '-':
+			name, value, hasValue := splitArg(a)
+			item, path := r.lookup(scope(), name)
+			if item == nil && len(name) > 2 && strings.HasPrefix(strings.ToLower(name), "no") {
+				// --noname sets a bool Var to false
+				if v, p := r.lookup(scope(), name[2:]); isBool(v) {
+					if hasValue {
+						return nil, fmt.Errorf(
+							"argument %d: negated Var %s does not take a value, found '%s'", i+1, p, value)
+					}
+					item, path, value, hasValue = v, p, "false", true
+				}
+			}
+			if inv.Command == nil && impliedAt == 0 && strings.Contains(path, "/") {
+				implied, impliedAt = a, i+1
+			}
+			switch x := item.(type) {
+			case Trigger:
+				if hasValue {
+					return nil, fmt.Errorf(
+						"argument %d: Trigger %s does not take a value, found '%s'", i+1, path, value)
+				}
+				inv.Triggers = append(inv.Triggers, x)
+			case Var:
+				if !hasValue && isBool(x) {
+					// a bool Var named without a value is set to true
+					value, hasValue = "true", true
+				}
+				if !hasValue {
+					if i+1 >= len(args) {
+						return nil, fmt.Errorf(
+							"argument %d: no value given for Var %s", i+1, path)
+					}
+					i++
+					value = args[i]
+				}
+				inv.values = append(inv.values, assignment{x, path, value, i + 1, false})
+			default:
+				return nil, fmt.Errorf("argument %d: unknown name '%s' in '%s'", i+1, name, a)
+			}
+		case inv.Command == nil:
+			c := r.command(a)
+			if c == nil && len(argsOf(def)) > 0 {
+				// the DefaultCommand takes the operands when it declares Args
+				inv.Command, inv.Path = def, []Command{def}
+				operand(i)
+				continue
+			}
+			if c == nil {
+				return nil, fmt.Errorf("argument %d: unknown command '%s'", i+1, a)
+			}
+			if impliedAt > 0 && !sameNode(c, def) {
+				return nil, fmt.Errorf("argument %d: '%s' belongs to the default command %s, not to command %s",
+					impliedAt, implied, nameOf(def), nameOf(c))
+			}
+			inv.Command, inv.Path = c, []Command{c}
+		case len(commandsOf(inv.Command)) > 0:
+			c := matchCommand(commandsOf(inv.Command), a)
+			if c == nil {
+				return nil, fmt.Errorf("argument %d: unknown command '%s' in %s", i+1, a, commandPath(inv.Path))
+			}
+			inv.Command, inv.Path = c, append(inv.Path, c)
+		default:
+			operand(i)
+		}
+	}
+	if inv.Command == nil && def != nil {
+		inv.Command, inv.Path = def, []Command{def}
+	}
+	if e := inv.assignArgs(); e != nil {
+		return nil, e
+	}
+	return inv, nil
+}
+
+// assignArgs matches the positional operands with the Args of the selected Command, if it declares any, and adds their values to those to be placed in their Slots.
+func (inv *Invocation) assignArgs() error {
+	args := argsOf(inv.Command)
+	if len(args) < 1 {
+		return nil
+	}
+	command := commandPath(inv.Path)
+	n := 0
+	for _, a := range args {
+		path := command + "/" + nameOf(a)
+		switch {
+		case hasFlag(a, Variadic{}):
+			if n < len(inv.Args) {
+				inv.values = append(inv.values, assignment{Var(a), path, append([]string{}, inv.Args[n:]...), inv.positions[n], true})
+				n = len(inv.Args)
+				continue
+			}
+		case n < len(inv.Args):
+			inv.values = append(inv.values, assignment{Var(a), path, inv.Args[n], inv.positions[n], true})
+			n++
+			continue
+		}
+		if !hasFlag(a, Optional{}) {
+			return fmt.Errorf("command %s requires the argument %s", command, argUsage(a))
+		}
+	}
+	if n < len(inv.Args) {
+		return fmt.Errorf("argument %d: unexpected argument '%s' for command %s", inv.positions[n], inv.Args[n], command)
+	}
+	return nil
+}
+
+// argsOf returns the Args of a Command, in the order they are declared.
+func argsOf(c Command) (args []Arg) {
+	for _, x := range c {
+		if a, ok := x.(Arg); ok {
+			args = append(args, a)
+		}
+	}
+	return
+}
+
+// argUsage returns the form of an Arg shown in usage lines, <name> if it is required and [name] if it is Optional, followed by ... if it is Variadic.
+func argUsage(a Arg) string {
+	name := strings.ToLower(nameOf(a))
+	if hasFlag(a, Variadic{}) {
+		name += "..."
+	}
+	if hasFlag(a, Optional{}) {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// apply places the values collected by scan into the Slots of their Vars and Args, in the order they appeared in the CLI args. As in the configuration file, the first occurrence of a Var with a []string Slot replaces a list that still holds its default, and every other occurrence adds to the list already in the Slot, such as one from the configuration file, and an empty value clears it, see AppendVar.
+func (inv *Invocation) apply() error {
+	for _, x := range inv.values {
+		set := ParseVar
+		if _, ok := slotOf(x.v).(*[]string); ok && !x.arg && (inv.given(x.v) || !isDefault(x.v)) {
+			set = AppendVar
+		}
+		if e := set(&x.v, x.value); e != nil {
+			return fmt.Errorf("argument %d: invalid value for Var %s: %v", x.index, x.path, e)
+		}
+		inv.assigned = append(inv.assigned, x.v)
+	}
+	return nil
+}
+
+// given returns true if the Var has been given a value in the configuration file or the CLI args of the Invocation.
+func (inv *Invocation) given(v Var) bool {
+	for _, x := range inv.assigned {
+		if sameNode(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArg removes the dash prefix from an argument and separates the name from a value joined to it with an equals sign.
+func splitArg(a string) (name, value string, hasValue bool) {
+	name = strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-")
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		return name[:i], name[i+1:], true
+	}
+	return name, "", false
+}
+
+// lookup finds the Var or Trigger that a name from the CLI args refers to, first in the selected Commands, innermost first, and then at the root of the Tri, and returns it along with its path.
+func (r *Tri) lookup(commands []Command, name string) (item interface{}, path string) {
+	for i := len(commands) - 1; i >= 0; i-- {
+		if item = findItem(commands[i], name); item != nil {
+			return item, commandPath(commands[:i+1]) + "/" + nameOf(item)
+		}
+	}
+	if item = findItem(*r, name); item != nil {
+		return item, nameOf(item)
+	}
+	return nil, ""
+}
+
+// Complete returns the words that could take the place of the last of the given CLI args, for shell completion, where the args are those typed so far, without the executable name, and the last is the partial word, which may be empty. A partial word starting with a dash is completed with the names of the Vars and Triggers at the root and in the selected Commands, or after the name of a Var with Allowed values and an equals sign, its values. Otherwise it is completed with the Allowed values of the Var named by the word before it, if it is waiting for a value, or with the Commands that can be named next, or the Allowed values of the Arg of the selected Command that the word is the operand for. Until a Command is named, the DefaultCommand is taken as selected, as in Parse, so its names are completed, and the values of its first Arg are offered after the Commands.
+func (r *Tri) Complete(args []string) (words []string) {
+	if len(args) < 1 {
+		args = []string{""}
+	}
+	word := args[len(args)-1]
+	var path []Command
+	def := r.defaultCommand()
+	// scope returns the Commands whose names are recognised, as in scan
+	scope := func() []Command {
+		if len(path) < 1 && def != nil {
+			return []Command{def}
+		}
+		return path
+	}
+	// expect is a Var named by the last word without a value, which takes the next word as its value
+	var expect Var
+	operands := 0
+	for _, a := range args[:len(args)-1] {
+		if expect != nil {
+			expect = nil
+			continue
+		}
+		if len(a) > 1 && a[0] == '-' {
+			name, _, hasValue := splitArg(a)
+			if item, _ := r.lookup(scope(), name); !hasValue && !isBool(item) {
+				expect, _ = item.(Var)
+			}
+			continue
+		}
+		if c := matchCommand(r.commandsAt(path), a); c != nil && operands == 0 {
+			path = append(path, c)
+		} else {
+			operands++
+		}
+	}
+	// offer adds the candidates that start with the partial word to the words, prefixed by what comes before the partial word
+	offer := func(candidates []string, before, partial string) {
+		for _, c := range candidates {
+			if strings.HasPrefix(c, partial) {
+				words = append(words, before+c)
+			}
+		}
+	}
+	// offerArg offers the Allowed values of the Arg of a Command that the partial word is the operand for
+	offerArg := func(c Command) {
+		args := argsOf(c)
+		if len(args) > 0 && operands >= len(args) && hasFlag(args[len(args)-1], Variadic{}) {
+			operands = len(args) - 1
+		}
+		if operands < len(args) {
+			offer(allowedStrings(args[operands]), "", word)
+		}
+	}
+	switch {
+	case expect != nil:
+		offer(allowedStrings(expect), "", word)
+	case len(word) > 0 && word[0] == '-':
+		name, value, hasValue := splitArg(word)
+		if hasValue {
+			item, _ := r.lookup(scope(), name)
+			if v, ok := item.(Var); ok {
+				offer(allowedStrings(v), word[:len(word)-len(value)], value)
+			}
+			return
+		}
+		var names []string
+		for _, container := range append([][]interface{}{*r}, commandNodes(scope())...) {
+			for _, x := range container {
+				switch x.(type) {
+				case Var, Trigger:
+					n := "--" + strings.ToLower(nameOf(x))
+					if indexOfString(names, n) < 0 {
+						names = append(names, n)
+					}
+				}
+			}
+		}
+		offer(names, "", "--"+strings.ToLower(name))
+	case operands == 0 && len(r.commandsAt(path)) > 0:
+		var names []string
+		for _, c := range r.commandsAt(path) {
+			names = append(names, strings.ToLower(nameOf(c)))
+		}
+		offer(names, "", strings.ToLower(word))
+		if len(path) < 1 && def != nil {
+			// a word that does not name a Command is the first operand of the DefaultCommand
+			offerArg(def)
+		}
+	case len(scope()) > 0:
+		offerArg(scope()[len(scope())-1])
+	}
+	return
+}
+
+// commandsAt returns the Commands that can be named after the given chain of Commands nested in each other, those of the last of them, or of the Tri if it is empty.
+func (r *Tri) commandsAt(path []Command) Commands {
+	if len(path) < 1 {
+		return r.commands()
+	}
+	return commandsOf(path[len(path)-1])
+}
+
+// commandNodes returns a chain of Commands as the nodes they are made of.
+func commandNodes(path []Command) (nodes [][]interface{}) {
+	for _, c := range path {
+		nodes = append(nodes, c)
+	}
+	return
+}
+
+// indexOfString returns the position of a string in a list of strings, or -1 if it is not found.
+func indexOfString(list []string, s string) int {
+	for i, x := range list {
+		if x == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// defaultCommand returns the Command named by the DefaultCommand of the Tri, or nil if it has none.
+func (r *Tri) defaultCommand() Command {
+	for _, x := range *r {
+		if d, ok := x.(DefaultCommand); ok && len(d) == 1 {
+			name, _ := d[0].(string)
+			return r.commandNamed(name)
+		}
+	}
+	return nil
+}
+
+// command returns the Command in the Tri's Commands whose name or Short matches the given word.
+func (r *Tri) command(word string) Command {
+	return matchCommand(r.commands(), word)
+}
+
+// matchCommand returns the Command in a Commands whose name or Short matches the given word.
+func matchCommand(cc Commands, word string) Command {
+	for _, c := range cc {
+		if matchName(c, word) {
+			return c
+		}
+	}
+	return nil
+}
+
+// commandsOf returns the Commands inside a Tri or Command, or nil if it has none.
+func commandsOf(node []interface{}) Commands {
+	for _, x := range node {
+		if c, ok := x.(Commands); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// commandPath returns the names of a Command and the Commands it is nested in, outermost first, separated by slashes.
+func commandPath(commands []Command) string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = nameOf(c)
+	}
+	return strings.Join(names, "/")
+}
+
+// findItem returns the Var or Trigger inside a container whose name or Short matches the given name.
+func findItem(container []interface{}, name string) interface{} {
+	for _, x := range container {
+		switch y := x.(type) {
+		case Var:
+			if matchName(y, name) {
+				return y
+			}
+		case Trigger:
+			if matchName(y, name) {
+				return y
+			}
+		}
+	}
+	return nil
+}
+
+// matchName returns true if the name matches the name of a Tri node, ignoring case, or if it is a single character, matches its Short.
+func matchName(node []interface{}, name string) bool {
+	if utf8.RuneCountInString(name) == 1 {
+		s, ok := shortOf(node)
+		r, _ := utf8.DecodeRuneInString(name)
+		return ok && s == r
+	}
+	return strings.EqualFold(nameOf(node), name)
+}
+
+// nameOf returns the name at the head of a Tri node, or an empty string if it doesn't have one.
+func nameOf(node interface{}) string {
+	var n []interface{}
+	switch x := node.(type) {
+	case Tri:
+		n = x
+	case Command:
+		n = x
+	case Var:
+		n = x
+	case Trigger:
+		n = x
+	case Arg:
+		n = x
+	case []interface{}:
+		n = x
+	}
+	if len(n) < 1 {
+		return ""
+	}
+	s, _ := n[0].(string)
+	return s
+}
+
+// shortOf returns the Short rune of a Tri node, if it has one.
+func shortOf(node []interface{}) (rune, bool) {
+	for _, x := range node {
+		if s, ok := x.(Short); ok && len(s) == 1 {
+			r, ok := s[0].(rune)
+			return r, ok
+		}
+	}
+	return 0, false
+}
+
+// sameNode returns true if two Tri nodes are the same element of a declaration, rather than merely equal.
+func sameNode(a, b []interface{}) bool {
+	return len(a) > 0 && len(b) > 0 && &a[0] == &b[0]
+}
+
+// isBool returns true if the item is a Var with a Slot pointing to a bool.
+func isBool(item interface{}) bool {
+	if v, ok := item.(Var); ok {
+		_, ok = slotOf(v).(*bool)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,480 @@
+package tri
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	var datadir, ctldir, rpcpass string
+	var port int
+	var limit uint32
+	var fee float64
+	var peers []string
+	var timeout time.Duration
+	tp := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"datadir", Short{'d'}, Brief{"brief"}, Slot{&datadir}},
+		Var{"port", Short{'p'}, Brief{"brief"}, Slot{&port}},
+		Var{"limit", Brief{"brief"}, Slot{&limit}},
+		Var{"fee", Brief{"brief"}, Slot{&fee}},
+		Var{"peers", Brief{"brief"}, Slot{&peers}},
+		Var{"timeout", Brief{"brief"}, Slot{&timeout}},
+		Trigger{"reindex", Short{'r'}, Brief{"brief"}, MakeTestHandler()},
+		Commands{
+			{"ctl", Short{'c'}, Brief{"brief"},
+				Var{"datadir", Short{'d'}, Brief{"brief"}, Override{}, Slot{&ctldir}},
+				Var{"rpcpass", Brief{"brief"}, Slot{&rpcpass}},
+				MakeTestHandler(),
+			},
+			{"node", Brief{"brief"}, MakeTestHandler()},
+		},
+	}
+	if e := tp.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+
+	// long and short names, with joined and separate values
+	size := len(tp)
+	inv, e := tp.Parse([]string{
+		"--datadir=/tmp/a", "-p", "11048", "-limit=1000", "--FEE", "0.1",
+		"--peers=a,b", "--timeout", "5s", "-r",
+	})
+	if e != nil {
+		t.Fatal("parser rejected valid args:", e)
+	}
+	if datadir != "/tmp/a" || port != 11048 || limit != 1000 || fee != 0.1 ||
+		len(peers) != 2 || timeout != 5*time.Second {
+		t.Error("parser did not place values in Slots")
+	}
+	if inv.Command != nil {
+		t.Error("parser selected a Command that was not named")
+	}
+	if tp.Invocation() != inv || len(tp) != size {
+		t.Error("Invocation was not kept outside the declaration", len(tp))
+	}
+	if len(inv.Triggers) != 1 || nameOf(inv.Triggers[0]) != "reindex" {
+		t.Error("parser did not record the named Trigger")
+	}
+
+	// Command selected by Short, its Vars shadow the root, operands collected
+	inv, e = tp.Parse([]string{"c", "-d", "/tmp/ctl", "operand", "--", "-d"})
+	if e != nil {
+		t.Fatal("parser rejected valid args:", e)
+	}
+	if nameOf(inv.Command) != "ctl" {
+		t.Error("parser did not select Command by its Short")
+	}
+	if ctldir != "/tmp/ctl" || datadir != "/tmp/a" {
+		t.Error("parser did not place value in the Command's Var")
+	}
+	if len(inv.Args) != 2 || inv.Args[0] != "operand" || inv.Args[1] != "-d" {
+		t.Error("parser did not collect positional operands")
+	}
+
+	// unknown name
+	if _, e = tp.Parse([]string{"--nothere=1"}); e == nil {
+		t.Error("parser accepted unknown name")
+	}
+	// Command Vars are not visible before the Command is named
+	if _, e = tp.Parse([]string{"--rpcpass", "x", "ctl"}); e == nil || !strings.Contains(e.Error(), "unknown name 'rpcpass'") {
+		t.Error("parser accepted Command Var before the Command:", e)
+	}
+	// root Vars are visible after the Command is named
+	if _, e = tp.Parse([]string{"-d", "x", "--fee", "1", "node", "--port=1"}); e != nil {
+		t.Error("parser rejected root Var after Command:", e)
+	}
+	// unknown command
+	if _, e = tp.Parse([]string{"wallet"}); e == nil {
+		t.Error("parser accepted unknown command")
+	}
+	// missing value
+	if _, e = tp.Parse([]string{"--port"}); e == nil {
+		t.Error("parser accepted Var without a value")
+	}
+	// value given to a Trigger
+	if _, e = tp.Parse([]string{"--reindex=yes"}); e == nil {
+		t.Error("parser accepted a value for a Trigger")
+	}
+	// []string Vars add to the list in their Slot with every occurrence, an empty value clears it
+	if _, e = tp.Parse([]string{"--peers", "c", "--peers=d,e"}); e != nil {
+		t.Fatal("parser rejected valid args:", e)
+	}
+	if strings.Join(peers, " ") != "a b c d e" {
+		t.Error("parser did not append to []string Var", peers)
+	}
+	if _, e = tp.Parse([]string{"--peers=", "--peers", "x"}); e != nil || len(peers) != 1 || peers[0] != "x" {
+		t.Error("parser did not clear []string Var", peers, e)
+	}
+	if _, e = tp.Parse([]string{"--peers", ""}); e != nil || len(peers) != 0 {
+		t.Error("parser did not clear []string Var", peers, e)
+	}
+	// byte sizes in uint32 Vars
+	for x, size := range map[string]uint32{
+		"64KiB": 64 << 10, "2m": 2 << 20, "3 GiB": 3 << 30, "4294967295": 4294967295,
+	} {
+		if _, e = tp.Parse([]string{"--limit", x}); e != nil || limit != size {
+			t.Error("parser did not read byte size", x, limit, e)
+		}
+	}
+	_, e = tp.Parse([]string{"--limit=4GiB"})
+	if e == nil || !strings.Contains(e.Error(), "limit") || !strings.Contains(e.Error(), "maximum") {
+		t.Error("parser did not reject oversized value naming the Var:", e)
+	}
+	// float64 values are truncated to 8 decimal places, or as declared
+	for x, f := range map[string]float64{
+		"0.123456789": 0.12345678, "-1.999999999": -1.99999999, "1e-9": 0,
+		"2.5E-7": 0.00000025, "0.1": 0.1, "21000000": 21000000,
+	} {
+		if _, e = tp.Parse([]string{"--fee", x}); e != nil || fee != f {
+			t.Error("parser did not truncate float64", x, fee, e)
+		}
+	}
+	var ratio, scale float64
+	tf := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"ratio", Brief{"brief"}, Precision{2}, Slot{&ratio}},
+		Var{"scale", Brief{"brief"}, Precision{-1}, Slot{&scale}},
+	}
+	if e = tf.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e = tf.Parse([]string{"--ratio=0.129", "--scale=1e-12"}); e != nil ||
+		ratio != 0.12 || scale != 1e-12 {
+		t.Error("parser did not use the declared Precision", ratio, scale, e)
+	}
+	// only plain decimal notation is accepted, also without truncation
+	for _, x := range []string{"--scale=0x1p-2", "--scale=NaN", "--scale=Inf", "--ratio=0x1p-2"} {
+		if _, e = tf.Parse([]string{x}); e == nil || !strings.Contains(e.Error(), "is not a floating point number") {
+			t.Error("parser accepted a number that is not decimal", x, e)
+		}
+	}
+	// malformed values
+	for _, x := range [][]string{
+		{"--fee=inf"}, {"--fee=1/3"}, {"--fee=0x1p-2"}, {"--fee=1_000"},
+		{"--port=abc"}, {"--limit=-1"}, {"--fee=one"}, {"--timeout=5"},
+		{"--limit=1.5MiB"}, {"--limit=KiB"}, {"--limit=99999999999999999999"},
+	} {
+		if _, e = tp.Parse(x); e == nil {
+			t.Error("parser accepted malformed value", x)
+		}
+	}
+
+	// bool Vars are set by name alone, negated with a no prefix, or given a value
+	var listen, upnp, nodes bool
+	tb := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"listen", Short{'l'}, Brief{"brief"}, Default{true}, Slot{&listen}},
+		Var{"upnp", Brief{"brief"}, Slot{&upnp}},
+		Var{"nodes", Brief{"brief"}, Slot{&nodes}},
+		Commands{{"node", Brief{"brief"}, MakeTestHandler()}},
+	}
+	if e = tb.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e := LoadAllDefaults(&tb); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	inv, e = tb.Parse([]string{"--nolisten", "--upnp", "node", "operand"})
+	if e != nil {
+		t.Fatal("parser rejected valid bool args:", e)
+	}
+	if listen || !upnp || nameOf(inv.Command) != "node" || len(inv.Args) != 1 {
+		t.Error("parser did not set bool Vars without consuming the next arg")
+	}
+	if _, e = tb.Parse([]string{"-l", "--upnp=false", "--nodes=true"}); e != nil {
+		t.Fatal("parser rejected valid bool args:", e)
+	}
+	if !listen || upnp || !nodes {
+		t.Error("parser did not set bool Vars from their values")
+	}
+	// a Var whose name starts with no is found before negation
+	if _, e = tb.Parse([]string{"--nodes=false", "--NoUPNP"}); e != nil || nodes || upnp {
+		t.Error("parser did not distinguish a Var named no... from a negation", e)
+	}
+	for _, x := range [][]string{
+		{"--listen=maybe"}, {"--nolisten=true"}, {"--noport"},
+	} {
+		if _, e = tb.Parse(x); e == nil {
+			t.Error("parser accepted invalid bool arg", x)
+		}
+	}
+
+	// Vars with a Handler are parsed, validated and formatted by it
+	var level []byte
+	var name string
+	th := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"level", Brief{"brief"}, Default{[]byte("info")}, Slot{&level},
+			Handler{
+				func(s string) (interface{}, error) { return []byte(strings.ToUpper(s)), nil },
+				func(v interface{}) string { return strings.ToLower(string(v.([]byte))) },
+			},
+		},
+		Var{"name", Brief{"brief"}, Slot{&name},
+			Handler{func(v interface{}) error {
+				if strings.ContainsAny(v.(string), " ") {
+					return errors.New("may not contain spaces")
+				}
+				return nil
+			}},
+		},
+	}
+	if e = th.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e := LoadAllDefaults(&th); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	if string(level) != "info" {
+		t.Error("default not loaded into Slot of a custom type")
+	}
+	if _, e = th.Parse([]string{"--level=debug", "--name", "node"}); e != nil {
+		t.Fatal("parser rejected valid args:", e)
+	}
+	if string(level) != "DEBUG" || name != "node" {
+		t.Error("parser did not use the Handler")
+	}
+	var b strings.Builder
+	if e = th.WriteConfig(&b, nil); e != nil || b.String() != "level debug\nname node\n" {
+		t.Errorf("writer did not format with the Handler: %q %v", b.String(), e)
+	}
+	if _, e = th.Parse([]string{"--name", "two words"}); e == nil || name != "node" {
+		t.Error("parser placed a value rejected by the Handler")
+	}
+
+	// nested Commands are selected one bare word at a time, the items of each are recognised, innermost first
+	var walletdir, accountdir, label string
+	tn := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"datadir", Short{'d'}, Brief{"brief"}, Slot{&datadir}},
+		Commands{
+			{"wallet", Short{'w'}, Brief{"brief"},
+				Var{"walletdir", Short{'W'}, Brief{"brief"}, Slot{&walletdir}},
+				Commands{
+					{"account", Short{'a'}, Brief{"brief"},
+						Var{"walletdir", Brief{"brief"}, Override{}, Slot{&accountdir}},
+						Commands{
+							{"create", Brief{"brief"},
+								Var{"label", Brief{"brief"}, Slot{&label}},
+								MakeTestHandler(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if e = tn.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	inv, e = tn.Parse([]string{"w", "-W", "/w", "account", "create", "--walletdir=/a", "--label", "x", "-d", "/d", "op"})
+	if e != nil {
+		t.Fatal("parser rejected valid nested args:", e)
+	}
+	if nameOf(inv.Command) != "create" || commandPath(inv.Path) != "wallet/account/create" ||
+		len(inv.Args) != 1 || inv.Args[0] != "op" {
+		t.Error("parser did not select the nested Command:", commandPath(inv.Path))
+	}
+	if walletdir != "/w" || accountdir != "/a" || label != "x" || datadir != "/d" {
+		t.Error("parser did not place values of every enclosing scope", walletdir, accountdir, label, datadir)
+	}
+	for _, x := range [][]string{
+		// unknown nested Command
+		{"wallet", "nothere"},
+		// items of a nested Command before it is selected
+		{"wallet", "--label=x", "account", "create"},
+	} {
+		if _, e = tn.Parse(x); e == nil {
+			t.Errorf("parser accepted invalid nested args %v", x)
+		}
+	}
+	_, e = tn.Parse([]string{"wallet", "account", "create", "--nothere=1"})
+	if e == nil || !strings.Contains(e.Error(), "nothere") {
+		t.Error("parser accepted unknown name in nested Command:", e)
+	}
+	_, e = tn.Parse([]string{"wallet", "account", "create", "--label"})
+	if e == nil || !strings.Contains(e.Error(), "wallet/account/create/label") {
+		t.Error("parser error does not show the path of the nested Var:", e)
+	}
+
+	// operands are placed in the Slots of the Args of the selected Command, in order
+	var address, memo string
+	var amount float64
+	var files []string
+	ta := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Commands{
+			{"send", Brief{"brief"},
+				Arg{"address", Brief{"brief"}, Slot{&address}},
+				Arg{"amount", Brief{"brief"}, Precision{2}, Slot{&amount}},
+				Arg{"memo", Brief{"brief"}, Optional{}, Default{"none"}, Slot{&memo}},
+				MakeTestHandler(),
+			},
+			{"sign", Brief{"brief"},
+				Arg{"files", Brief{"brief"}, Variadic{}, Default{[]string{"default"}}, Slot{&files}},
+				MakeTestHandler(),
+			},
+		},
+	}
+	if e = ta.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e := LoadAllDefaults(&ta); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	inv, e = ta.Parse([]string{"send", "addr", "1.239"})
+	if e != nil || address != "addr" || amount != 1.23 || memo != "none" || len(inv.Args) != 2 {
+		t.Error("parser did not place operands in Args", address, amount, memo, e)
+	}
+	if _, e = ta.Parse([]string{"send", "addr", "--", "2", "-x"}); e != nil || amount != 2 || memo != "-x" {
+		t.Error("parser did not place operands after -- in Args", amount, memo, e)
+	}
+	if _, e = ta.Parse([]string{"sign", "a,b", "c"}); e != nil || strings.Join(files, " ") != "a,b c" {
+		t.Error("parser did not replace the list of the Variadic Arg", files, e)
+	}
+	for _, x := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"send", "addr"}, "requires the argument <amount>"},
+		{[]string{"send", "addr", "1", "memo", "extra"}, "argument 5: unexpected argument 'extra'"},
+		{[]string{"send", "addr", "x"}, "argument 3: invalid value for Var send/amount"},
+		{[]string{"sign"}, "requires the argument <files...>"},
+	} {
+		if _, e = ta.Parse(x.args); e == nil || !strings.Contains(e.Error(), x.err) {
+			t.Errorf("parser error for %v does not contain %q: %v", x.args, x.err, e)
+		}
+	}
+
+	// the names and Args of the DefaultCommand are recognised until a Command is named
+	var sendfee int
+	ta = append(ta, DefaultCommand{"send"})
+	for _, x := range ta {
+		if c, ok := x.(Commands); ok {
+			c[0] = append(c[0], Var{"fee", Brief{"brief"}, Slot{&sendfee}})
+		}
+	}
+	if e = ta.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	inv, e = ta.Parse([]string{"--fee", "2", "other", "3"})
+	if e != nil || nameOf(inv.Command) != "send" || sendfee != 2 || address != "other" || amount != 3 {
+		t.Error("parser did not use the DefaultCommand", sendfee, address, amount, e)
+	}
+	if _, e = ta.Parse([]string{"--fee", "2", "send", "addr", "1"}); e != nil || sendfee != 2 {
+		t.Error("parser did not accept the DefaultCommand named after its Var", sendfee, e)
+	}
+	for _, x := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--fee", "1", "sign", "a"}, "argument 1: '--fee' belongs to the default command send, not to command sign"},
+		{[]string{"sign", "--fee", "1"}, "argument 2: unknown name 'fee'"},
+		{[]string{"--fee", "1"}, "requires the argument <address>"},
+	} {
+		if _, e = ta.Parse(x.args); e == nil || !strings.Contains(e.Error(), x.err) {
+			t.Errorf("parser error for %v does not contain %q: %v", x.args, x.err, e)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	var network, kind, account string
+	var levels []string
+	tc := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"network", Brief{"brief"}, Allowed{"mainnet", "testnet", "simnet"}, Default{"mainnet"}, Slot{&network}},
+		Var{"levels", Brief{"brief"}, Allowed{"info", "debug"}, Slot{&levels}},
+		Trigger{"reindex", Brief{"brief"}, MakeTestHandler()},
+		Commands{
+			{"wallet", Brief{"brief"},
+				Var{"network", Brief{"brief"}, Override{}, Allowed{"mainnet", "regtest"}, Slot{&network}},
+				Commands{
+					{"address", Brief{"brief"},
+						Arg{"account", Brief{"brief"}, Slot{&account}},
+						Arg{"kind", Brief{"brief"}, Allowed{"legacy", "segwit"}, Slot{&kind}},
+						MakeTestHandler(),
+					},
+				},
+			},
+			{"Node", Brief{"brief"}, MakeTestHandler()},
+		},
+	}
+	if e := tc.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	// values that are not Allowed are rejected in the CLI args
+	if _, e := tc.Parse([]string{"--network", "regtest"}); e == nil || !strings.Contains(e.Error(), "must be one of mainnet, testnet, simnet") {
+		t.Error("parser accepted value that is not Allowed:", e)
+	}
+	if _, e := tc.Parse([]string{"wallet", "--network", "regtest"}); e != nil || network != "regtest" {
+		t.Error("parser rejected value Allowed in the overriding Var:", e)
+	}
+	if _, e := tc.Parse([]string{"wallet", "address", "me", "p2sh"}); e == nil || !strings.Contains(e.Error(), "wallet/address/kind") {
+		t.Error("parser accepted operand that is not Allowed:", e)
+	}
+	for _, x := range []struct {
+		args  []string
+		words string
+	}{
+		{nil, "wallet node help"},
+		{[]string{"w"}, "wallet"},
+		{[]string{"N"}, "node"},
+		{[]string{"--"}, "--network --levels --reindex --init --save --defaults --datadir"},
+		{[]string{"-re"}, "--reindex"},
+		{[]string{"--network", "t"}, "testnet"},
+		{[]string{"--network="}, "--network=mainnet --network=testnet --network=simnet"},
+		{[]string{"--levels=d"}, "--levels=debug"},
+		{[]string{"wallet", "--network", ""}, "mainnet regtest"},
+		{[]string{"wallet", "--network", "regtest", ""}, "address"},
+		{[]string{"wallet", "address", "me", ""}, "legacy segwit"},
+		{[]string{"wallet", "address", ""}, ""},
+		{[]string{"node", "--reindex", ""}, ""},
+	} {
+		if words := strings.Join(tc.Complete(x.args), " "); words != x.words {
+			t.Errorf("completion of %q: expected %q, got %q", x.args, x.words, words)
+		}
+	}
+
+	// the names and Args of the DefaultCommand are offered until a Command is named
+	var level string
+	var verbose bool
+	td := Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		DefaultCommand{"log"},
+		Commands{
+			{"log", Brief{"brief"},
+				Var{"verbose", Brief{"brief"}, Slot{&verbose}},
+				Arg{"level", Brief{"brief"}, Allowed{"debug", "info"}, Slot{&level}},
+				MakeTestHandler(),
+			},
+			{"dump", Brief{"brief"}, MakeTestHandler()},
+		},
+	}
+	if e := td.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	for _, x := range []struct {
+		args  []string
+		words string
+	}{
+		{[]string{"d"}, "dump debug"},
+		{[]string{"--verbose", "i"}, "info"},
+		{[]string{"--v"}, "--verbose"},
+		{[]string{"dump", "--v"}, ""},
+		{[]string{"debug", ""}, ""},
+	} {
+		if words := strings.Join(td.Complete(x.args), " "); words != x.words {
+			t.Errorf("completion of %q: expected %q, got %q", x.args, x.words, words)
+		}
+	}
+}
@@ -22,16 +22,8 @@ var exampleTri = Tri{
 	Brief{"brief"},
 	Version{0, 1, 1, "alpha"},
 	DefaultCommand{"ctl"},
-	Var{"datadir",
-		Short{'d'},
-		Brief{"brief"},
-		Usage{"usage"},
-		Help{"help"},
-		Default{"~/.pod"},
-		Slot{&cfg.datadir},
-	},
-	Trigger{"init",
-		Short{'I'},
+	Trigger{"backup",
+		Short{'b'},
 		Brief{"brief"},
 		Usage{"usage"},
 		Help{"help"},
@@ -58,6 +50,7 @@ var exampleTri = Tri{
 				Help{"help"},
 				Default{"~/.pod"},
 				Group{"groupname"},
+				Override{},
 				Slot{&cfg.datadir, &cfg2.datadir},
 			},
 			Trigger{"wallet",
@@ -1,14 +1,9 @@
 package main
 
 import (
-	"git.parallelcoin.io/tri"
-	"fmt"
+	"os"
 )
 
 func main() {
-	e := exampleTri.Validate()
-	if e != nil {
-		fmt.Println(e)
-	}
-	tri.LoadAllDefaults(&exampleTri)
+	os.Exit(exampleTri.Run(os.Args[1:]))
 }
@@ -0,0 +1,348 @@
+package tri
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// LoadConfig reads the configuration file at the given path with ReadConfig. A configuration file that does not exist is not an error, it simply means everything is at its default.
+func (r *Tri) LoadConfig(path string) ([]Trigger, error) {
+	triggers, _, e := r.loadConfig(path)
+	return triggers, e
+}
+
+// loadConfig is LoadConfig, also returning the Vars that were given a value in the configuration file.
+func (r *Tri) loadConfig(path string) ([]Trigger, []Var, error) {
+	f, e := os.Open(path)
+	if os.IsNotExist(e) {
+		return nil, nil, nil
+	} else if e != nil {
+		return nil, nil, e
+	}
+	defer f.Close()
+	return r.readConfig(f)
+}
+
+// ReadConfig parses a configuration in the format described in doc/configformat.md and places the values it contains into the Slots of the Vars they name. It should be run after the defaults are loaded and before the CLI args are parsed, so that configuration overrides defaults and CLI args override configuration.
+//
+// Commands nested in other Commands are named by their path, as in commandname/subcommandname, at the start of the line, followed by their items prefixed by a tab.
+//
+// Triggers named in the configuration are returned, only DefaultOn Triggers may appear, their presence disables them as though they were named in the CLI args.
+//
+// The first occurrence of a Var with a []string Slot replaces its default, and later ones add to it, an occurrence with no items clears the list.
+//
+// Any line with a name that does not exist in the Tri, or a value that is not valid for the Var it names, halts parsing and returns an error showing the line, with its previous and next lines.
+func (r *Tri) ReadConfig(rd io.Reader) ([]Trigger, error) {
+	triggers, _, e := r.readConfig(rd)
+	return triggers, e
+}
+
+// readConfig is ReadConfig, also returning the Vars that were given a value, in the order they were first found.
+func (r *Tri) readConfig(rd io.Reader) (triggers []Trigger, assigned []Var, e error) {
+	var lines []string
+	s := bufio.NewScanner(rd)
+	for s.Scan() {
+		lines = append(lines, strings.TrimSuffix(s.Text(), "\r"))
+	}
+	if e = s.Err(); e != nil {
+		return nil, nil, e
+	}
+	// command is the Command whose items are on the lines prefixed by a tab, with the Commands it is nested in, outermost first
+	var command []Command
+	// array is the Var with a []string Slot that two-tab lines are collected into
+	var array *assignment
+	var items []string
+	// lists are the Vars with a []string Slot already set, further occurrences add to them
+	var lists []Var
+	setList := func(v Var, value interface{}) error {
+		for _, x := range lists {
+			if sameNode(x, v) {
+				return AppendVar(&v, value)
+			}
+		}
+		lists = append(lists, v)
+		assigned = append(assigned, v)
+		return ParseVar(&v, value)
+	}
+	closeArray := func() error {
+		if array == nil {
+			return nil
+		}
+		a := array
+		array = nil
+		if e := setList(a.v, items); e != nil {
+			return configError(lines, a.index, "invalid value for Var %s: %v", a.path, e)
+		}
+		return nil
+	}
+	for i, l := range lines {
+		tabs := len(l) - len(strings.TrimLeft(l, "\t"))
+		content := l[tabs:]
+		if len(content) < 1 || (tabs == 0 && !unicode.IsLetter([]rune(content)[0])) {
+			continue
+		}
+		if tabs == 2 {
+			if array == nil {
+				return nil, nil, configError(lines, i, "array item without a parent Var")
+			}
+			items = append(items, content)
+			continue
+		}
+		if e = closeArray(); e != nil {
+			return nil, nil, e
+		}
+		if tabs > 2 {
+			return nil, nil, configError(lines, i, "too many tabs at start of line")
+		}
+		name, value := content, ""
+		hasValue := false
+		if j := strings.IndexByte(content, ' '); j >= 0 {
+			name, value, hasValue = content[:j], content[j+1:], true
+		}
+		// Commands nested in other Commands are named by their path
+		names := []string{name}
+		if tabs == 0 {
+			names = strings.Split(name, "/")
+		}
+		for _, n := range names {
+			if e = ValidName(n); e != nil {
+				return nil, nil, configError(lines, i, "invalid name '%s': %v", name, e)
+			}
+		}
+		var item interface{}
+		var path string
+		switch tabs {
+		case 0:
+			if c := r.commandAt(name); c != nil {
+				if hasValue {
+					return nil, nil, configError(lines, i, "command %s may not have a value", commandPath(c))
+				}
+				command = c
+				continue
+			}
+			if len(names) > 1 {
+				return nil, nil, configError(lines, i, "unknown command %s", strings.ToLower(name))
+			}
+			item = itemNamed(*r, name)
+			path = strings.ToLower(name)
+		case 1:
+			if command == nil {
+				return nil, nil, configError(lines, i, "command item '%s' found before any command name", name)
+			}
+			item = itemNamed(command[len(command)-1], name)
+			path = commandPath(command) + "/" + strings.ToLower(name)
+		}
+		switch x := item.(type) {
+		case Trigger:
+			if hasValue {
+				return nil, nil, configError(lines, i, "Trigger %s may not have a value", path)
+			}
+			if !isDefaultOn(x) {
+				return nil, nil, configError(lines, i, "Trigger %s is not DefaultOn and cannot be set in configuration", path)
+			}
+			triggers = append(triggers, x)
+		case Var:
+			if sameNode(x, r.dataDirVar()) {
+				return nil, nil, configError(lines, i, "%s cannot be set in the configuration file, which is inside it", path)
+			}
+			if !hasValue {
+				if _, ok := slotOf(x).(*[]string); ok {
+					array, items = &assignment{x, path, "", i, false}, []string{}
+					continue
+				}
+				if isBool(x) {
+					value, hasValue = "true", true
+				}
+			}
+			if !hasValue {
+				return nil, nil, configError(lines, i, "no value given for Var %s", path)
+			}
+			if _, ok := slotOf(x).(*[]string); ok {
+				e = setList(x, value)
+			} else if e = ParseVar(&x, value); e == nil {
+				assigned = append(assigned, x)
+			}
+			if e != nil {
+				return nil, nil, configError(lines, i, "invalid value for Var %s: %v", path, e)
+			}
+		default:
+			return nil, nil, configError(lines, i, "unknown name %s", path)
+		}
+	}
+	if e = closeArray(); e != nil {
+		return nil, nil, e
+	}
+	return triggers, assigned, nil
+}
+
+// configError formats an error found in line i of a configuration, showing the line along with its previous and next lines.
+func configError(lines []string, i int, format string, a ...interface{}) error {
+	msg := fmt.Sprintf("configuration line %d: ", i+1) + fmt.Sprintf(format, a...)
+	for j := i - 1; j <= i+1; j++ {
+		if j < 0 || j >= len(lines) {
+			continue
+		}
+		marker := " "
+		if j == i {
+			marker = ">"
+		}
+		msg += fmt.Sprintf("\n%s %4d: %q", marker, j+1, lines[j])
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// commandNamed returns the Command in the Tri's Commands with the given name, ignoring case.
+func (r *Tri) commandNamed(name string) Command {
+	for _, x := range *r {
+		if c, ok := x.(Commands); ok {
+			for _, y := range c {
+				if strings.EqualFold(nameOf(y), name) {
+					return y
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// itemNamed returns the Var or Trigger inside a container with the given name, ignoring case. Unlike findItem it does not match Short runes.
+func itemNamed(container []interface{}, name string) interface{} {
+	for _, x := range container {
+		switch y := x.(type) {
+		case Var:
+			if strings.EqualFold(nameOf(y), name) {
+				return y
+			}
+		case Trigger:
+			if strings.EqualFold(nameOf(y), name) {
+				return y
+			}
+		}
+	}
+	return nil
+}
+
+// slotOf returns the first pointer in the Slot of a Var, or nil if it has none.
+func slotOf(v Var) interface{} {
+	for _, x := range v {
+		if s, ok := x.(Slot); ok && len(s) > 0 {
+			return s[0]
+		}
+	}
+	return nil
+}
+
+// isDefaultOn returns true if the Trigger contains a DefaultOn flag.
+func isDefaultOn(t Trigger) bool {
+	for _, x := range t {
+		if _, ok := x.(DefaultOn); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveConfig writes the configuration produced by WriteConfig to the file at the given path, replacing its previous contents.
+func (r *Tri) SaveConfig(path string, triggers []Trigger) error {
+	var b bytes.Buffer
+	if e := r.WriteConfig(&b, triggers); e != nil {
+		return e
+	}
+	f, e := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if e != nil {
+		return e
+	}
+	if _, e = f.Write(b.Bytes()); e != nil {
+		f.Close()
+		return e
+	}
+	return f.Close()
+}
+
+// WriteConfig writes the state of the Tri in the configuration format. Only Vars whose Slot holds a value different from their Default (or the zero value, if they have no Default) are written, so the configuration never contains redundant defaults. Of the given Triggers, those that are DefaultOn are written in the scope they are declared in, recording that they are disabled.
+//
+// Root items come first, followed by every Command name, except the built-in Commands, even those with no items, with the Command's items after it prefixed by a tab. Commands nested in other Commands follow the Command they are in, named by their path, as in commandname/subcommandname. Items of []string Vars follow the Var's name on their own lines prefixed by two tabs. The datadir Var is never written, as the configuration file is inside it. Names are written in lower case and in the order of the declaration, so the output is the same for the same state.
+func (r *Tri) WriteConfig(w io.Writer, triggers []Trigger) error {
+	return r.write(w, false, triggers)
+}
+
+// WriteDefaults writes every Var in the Tri in the configuration format with its default value, whether or not it is the value currently in its Slot. Lists with only one item in their default are written on the same line as their name, unless the item contains a comma, which would split it in two when it is read.
+func (r *Tri) WriteDefaults(w io.Writer) error {
+	return r.write(w, true, nil)
+}
+
+// write produces the output of WriteConfig and WriteDefaults.
+func (r *Tri) write(w io.Writer, defaults bool, triggers []Trigger) error {
+	var b bytes.Buffer
+	// the data directory holds the configuration file, so it is never written in it
+	var root []interface{}
+	for _, x := range *r {
+		if v, ok := x.(Var); ok && sameNode(v, r.dataDirVar()) {
+			continue
+		}
+		root = append(root, x)
+	}
+	if e := writeItems(&b, root, "", defaults, triggers); e != nil {
+		return e
+	}
+	for _, chain := range r.commandChains() {
+		if isBuiltin(chain[0]) {
+			continue
+		}
+		fmt.Fprintln(&b, strings.ToLower(commandPath(chain)))
+		if e := writeItems(&b, chain[len(chain)-1], "\t", defaults, triggers); e != nil {
+			return e
+		}
+	}
+	_, e := w.Write(b.Bytes())
+	return e
+}
+
+// writeItems writes the Vars and Triggers of a container with the given prefix. Unless all defaults are being written, only Vars that are not at their default and disabled DefaultOn Triggers are written.
+func writeItems(b *bytes.Buffer, container []interface{}, prefix string, defaults bool, triggers []Trigger) error {
+	for _, x := range container {
+		switch y := x.(type) {
+		case Trigger:
+			if defaults || !isDefaultOn(y) {
+				continue
+			}
+			for _, t := range triggers {
+				if sameNode(t, y) {
+					fmt.Fprintf(b, "%s%s\n", prefix, strings.ToLower(nameOf(y)))
+					break
+				}
+			}
+		case Var:
+			name := strings.ToLower(nameOf(y))
+			value := slotValue(y)
+			if defaults {
+				value = defaultValue(y)
+			} else if isDefault(y) {
+				continue
+			}
+			if list, ok := value.([]string); ok && !(defaults && len(list) == 1 && !strings.Contains(list[0], ",")) {
+				fmt.Fprintf(b, "%s%s\n", prefix, name)
+				for _, item := range list {
+					if item == "" || item[0] == '\t' || strings.ContainsAny(item, "\r\n") {
+						return fmt.Errorf(
+							"item %q of Var %s cannot be written to configuration", item, name)
+					}
+					fmt.Fprintf(b, "\t\t%s\n", item)
+				}
+				continue
+			}
+			s := formatVar(y, value)
+			if strings.ContainsAny(s, "\r\n") {
+				return fmt.Errorf(
+					"value %q of Var %s cannot be written to configuration", s, name)
+			}
+			fmt.Fprintf(b, "%s%s %s\n", prefix, name, s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+package tri
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	var logdir, ctldir string
+	var port int
+	var peers []string
+	tc := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"logdir", Brief{"brief"}, Slot{&logdir}},
+		Var{"port", Brief{"brief"}, Slot{&port}},
+		Var{"peers", Brief{"brief"}, Default{[]string{"default"}}, Slot{&peers}},
+		Trigger{"wallet", Brief{"brief"}, DefaultOn{}, MakeTestHandler()},
+		Trigger{"reindex", Brief{"brief"}, MakeTestHandler()},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Var{"datadir", Brief{"brief"}, Override{}, Slot{&ctldir}},
+				MakeTestHandler(),
+			},
+			{"node", Brief{"brief"}, MakeTestHandler()},
+		},
+	}
+	if e := tc.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+
+	// values for root and command items, arrays, DefaultOn triggers and ignored lines
+	conf := strings.Join([]string{
+		"# comment lines are ignored",
+		"LogDir /path/with spaces\tand tabs",
+		"port 11048",
+		"peers",
+		"\t\tone,two",
+		"\t\tthree",
+		"wallet",
+		"",
+		"ctl",
+		"\tdatadir /ctl/dir",
+		"node",
+	}, "\n")
+	triggers, e := tc.ReadConfig(strings.NewReader(conf))
+	if e != nil {
+		t.Fatal("reader rejected valid configuration:", e)
+	}
+	if logdir != "/path/with spaces\tand tabs" || port != 11048 || ctldir != "/ctl/dir" {
+		t.Error("reader did not place values in Slots")
+	}
+	if len(peers) != 2 || peers[0] != "one,two" || peers[1] != "three" {
+		t.Error("reader did not collect array items", peers)
+	}
+	if len(triggers) != 1 || nameOf(triggers[0]) != "wallet" {
+		t.Error("reader did not return DefaultOn trigger")
+	}
+
+	// an array with no items clears the list
+	if _, e = tc.ReadConfig(strings.NewReader("peers\nnode")); e != nil || len(peers) != 0 {
+		t.Error("reader did not clear array with no items")
+	}
+	// later occurrences add to the list, and an empty one clears what came before
+	if _, e := LoadAllDefaults(&tc); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	if _, e = tc.ReadConfig(strings.NewReader("peers\n\t\ta\nport 1\npeers b,c")); e != nil ||
+		strings.Join(peers, " ") != "a b c" {
+		t.Error("reader did not append repeated array", peers, e)
+	}
+	if _, e = tc.ReadConfig(strings.NewReader("peers\n\t\ta\npeers\npeers\n\t\tb")); e != nil ||
+		strings.Join(peers, " ") != "b" {
+		t.Error("reader did not clear array with no items", peers, e)
+	}
+	// CLI args add to the list from the configuration, the Default is left alone
+	if _, e = tc.Parse([]string{"--peers=c"}); e != nil || strings.Join(peers, " ") != "b c" {
+		t.Error("CLI args did not add to the list from the configuration", peers, e)
+	}
+	if _, e := LoadAllDefaults(&tc); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	// as in the configuration, the first occurrence in the CLI args replaces the default list, later ones add to it
+	if _, e = tc.Parse([]string{"--peers=x", "--peers", "y"}); e != nil || strings.Join(peers, " ") != "x y" ||
+		strings.Join(defaultValue(tc[5].(Var)).([]string), " ") != "default" {
+		t.Error("CLI args did not replace the default list", peers, e)
+	}
+	// an empty value clears the list however it is given, and empty items are dropped
+	if _, e = tc.ReadConfig(strings.NewReader("peers \n")); e != nil || peers == nil || len(peers) != 0 {
+		t.Error("reader did not clear the list with an empty value", peers, e)
+	}
+	if e = tc.WriteConfig(new(bytes.Buffer), nil); e != nil {
+		t.Error("list cleared by an empty value could not be written:", e)
+	}
+	if _, e = tc.Parse([]string{"--peers", "a,,b,"}); e != nil || strings.Join(peers, " ") != "a b" {
+		t.Error("CLI args added empty items to the list", peers, e)
+	}
+	if _, e = tc.ReadConfig(strings.NewReader("peers ,a,,b\n")); e != nil || strings.Join(peers, " ") != "a b" {
+		t.Error("reader added empty items to the list", peers, e)
+	}
+
+	for _, x := range []string{
+		// unknown root name
+		"nothere 1",
+		// unknown command item
+		"ctl\n\tport 1",
+		// command item before command
+		"\tdatadir /x",
+		// invalid value
+		"port abc",
+		// missing value
+		"port",
+		// trigger with a value
+		"wallet off",
+		// trigger that is not DefaultOn
+		"reindex",
+		// command with a value
+		"ctl x",
+		// array item without array
+		"port 1\n\t\titem",
+		// invalid name
+		"port1 1",
+	} {
+		if _, e = tc.ReadConfig(strings.NewReader(x)); e == nil {
+			t.Errorf("reader accepted invalid configuration %q", x)
+		}
+	}
+
+	// errors show the path and the surrounding lines
+	_, e = tc.ReadConfig(strings.NewReader("port 1\nctl\n\tport 2\nnode"))
+	if e == nil || !strings.Contains(e.Error(), "line 3") ||
+		!strings.Contains(e.Error(), "ctl/port") ||
+		!strings.Contains(e.Error(), `"ctl"`) || !strings.Contains(e.Error(), `"node"`) {
+		t.Error("reader error does not show the position of the error:", e)
+	}
+
+	// bool Vars may be set by name alone, and are written with their value
+	var listen, upnp bool
+	tb := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"listen", Brief{"brief"}, Default{true}, Slot{&listen}},
+		Var{"upnp", Brief{"brief"}, Slot{&upnp}},
+	}
+	if e = tb.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e := LoadAllDefaults(&tb); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	if _, e = tb.ReadConfig(strings.NewReader("listen false\nupnp")); e != nil || listen || !upnp {
+		t.Error("reader did not set bool Vars", e)
+	}
+	var b strings.Builder
+	if e = tb.WriteConfig(&b, nil); e != nil || b.String() != "listen false\nupnp true\n" {
+		t.Errorf("writer did not write bool Vars: %q %v", b.String(), e)
+	}
+	if _, e = tb.ReadConfig(strings.NewReader("upnp maybe")); e == nil {
+		t.Error("reader accepted invalid bool value")
+	}
+
+	// byte sizes are read with suffixes and written with the largest exact one
+	var cache, limit uint32
+	ts := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"cache", Brief{"brief"}, Default{uint32(1 << 20)}, Slot{&cache}},
+		Var{"limit", Brief{"brief"}, Slot{&limit}},
+	}
+	if e = ts.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e = ts.ReadConfig(strings.NewReader("cache 1536KiB\nlimit 1000")); e != nil ||
+		cache != 1536<<10 || limit != 1000 {
+		t.Error("reader did not read byte sizes", e)
+	}
+	b.Reset()
+	if e = ts.WriteConfig(&b, nil); e != nil || b.String() != "cache 1536KiB\nlimit 1000\n" {
+		t.Errorf("writer did not write byte sizes: %q %v", b.String(), e)
+	}
+	b.Reset()
+	if e = ts.WriteDefaults(&b); e != nil || b.String() != "cache 1MiB\nlimit 0\n" {
+		t.Errorf("defaults writer did not write byte sizes: %q %v", b.String(), e)
+	}
+	_, e = ts.ReadConfig(strings.NewReader("limit 8GiB"))
+	if e == nil || !strings.Contains(e.Error(), "limit") {
+		t.Error("reader did not reject oversized value naming the Var:", e)
+	}
+
+	// a default list item containing a comma is written so it is read back as one item
+	var list []string
+	tl := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"list", Brief{"brief"}, Default{[]string{"a,b"}}, Slot{&list}},
+	}
+	if e = tl.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	b.Reset()
+	if e = tl.WriteDefaults(&b); e != nil || b.String() != "list\n\t\ta,b\n" {
+		t.Errorf("defaults writer split a list item at its comma: %q %v", b.String(), e)
+	}
+	if _, e = tl.ReadConfig(strings.NewReader(b.String())); e != nil || len(list) != 1 || list[0] != "a,b" {
+		t.Error("list item with a comma was not read back as one item:", list, e)
+	}
+
+	// values that are not Allowed are rejected, naming the Var
+	var network string
+	var levels []string
+	tn := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"network", Brief{"brief"}, Allowed{"mainnet", "testnet"}, Default{"mainnet"}, Slot{&network}},
+		Var{"levels", Brief{"brief"}, Allowed{"info", "debug"}, Slot{&levels}},
+	}
+	if e = tn.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e = tn.ReadConfig(strings.NewReader("network testnet\nlevels\n\t\tdebug")); e != nil ||
+		network != "testnet" || strings.Join(levels, " ") != "debug" {
+		t.Error("reader did not read Allowed values", e)
+	}
+	for _, x := range []string{"network simnet", "levels\n\t\tinfo\n\t\ttrace"} {
+		_, e = tn.ReadConfig(strings.NewReader(x))
+		if e == nil || !strings.Contains(e.Error(), "must be one of") {
+			t.Errorf("reader accepted value that is not Allowed %q: %v", x, e)
+		}
+	}
+
+	// Sentinels are read and written by name, and values outside Min and Max are rejected
+	var threads int
+	var blocks uint32
+	tr := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"genthreads", Brief{"brief"}, Min{1}, Max{64}, Sentinels{-1, "all"}, Default{-1}, Slot{&threads}},
+		Var{"blocks", Brief{"brief"}, Min{1}, Default{uint32(16)}, Slot{&blocks}},
+	}
+	if e = tr.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	b.Reset()
+	if e = tr.WriteDefaults(&b); e != nil || b.String() != "genthreads all\nblocks 16\n" {
+		t.Errorf("defaults writer did not write Sentinel by name: %q %v", b.String(), e)
+	}
+	if _, e = tr.ReadConfig(strings.NewReader("genthreads 4\nblocks 1KiB")); e != nil || threads != 4 || blocks != 1024 {
+		t.Error("reader did not read values in range", e)
+	}
+	if _, e = tr.ReadConfig(strings.NewReader("genthreads ALL")); e != nil || threads != -1 {
+		t.Error("reader did not read Sentinel by name", e)
+	}
+	for _, x := range []string{"genthreads 65", "genthreads 0", "genthreads -2", "blocks 0"} {
+		_, e = tr.ReadConfig(strings.NewReader(x))
+		if e == nil || !strings.Contains(e.Error(), "imum of") {
+			t.Errorf("reader accepted value out of range %q: %v", x, e)
+		}
+	}
+
+	// invalid URLs are rejected naming the path of the Var, in the configuration and the CLI args
+	var rpc string
+	tu := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Var{"rpc", Brief{"brief"}, URL{"http", "https"}, Host{}, Slot{&rpc}},
+				MakeTestHandler(),
+			},
+		},
+	}
+	if e = tu.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	_, e = tu.ReadConfig(strings.NewReader("ctl\n\trpc ftp://host"))
+	if e == nil || !strings.Contains(e.Error(), "ctl/rpc") || !strings.Contains(e.Error(), "must be one of http, https") {
+		t.Error("reader error for invalid URL does not name the Var:", e)
+	}
+	_, e = tu.Parse([]string{"ctl", "--rpc", "http:///path"})
+	if e == nil || !strings.Contains(e.Error(), "Var ctl/rpc") || !strings.Contains(e.Error(), "no host") {
+		t.Error("parser error for invalid URL does not name the Var:", e)
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	var logdir, ctldir string
+	var port int
+	var peers []string
+	tc := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"LogDir", Brief{"brief"}, Default{"~/.appname"}, Slot{&logdir}},
+		Var{"port", Brief{"brief"}, Default{11048}, Slot{&port}},
+		Var{"peers", Brief{"brief"}, Default{[]string{"default"}}, Slot{&peers}},
+		Trigger{"wallet", Brief{"brief"}, DefaultOn{}, MakeTestHandler()},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Var{"datadir", Brief{"brief"}, Override{}, Slot{&ctldir}},
+				Trigger{"wallet", Brief{"brief"}, DefaultOn{}, Override{}, MakeTestHandler()},
+				MakeTestHandler(),
+			},
+			{"node", Brief{"brief"}, MakeTestHandler()},
+		},
+	}
+	if e := tc.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if _, e := LoadAllDefaults(&tc); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+
+	// nothing but the command names when everything is default
+	var b strings.Builder
+	if e := tc.WriteConfig(&b, nil); e != nil {
+		t.Fatal("writer failed:", e)
+	}
+	if b.String() != "ctl\nnode\n" {
+		t.Errorf("writer wrote default values:\n%s", b.String())
+	}
+
+	// only changed values and disabled DefaultOn triggers of the right scope
+	logdir, ctldir, peers = "/data", "/ctl", []string{"one", "two"}
+	ctl := tc[7].(Commands)[0]
+	wallet := ctl[3].(Trigger)
+	b.Reset()
+	if e := tc.WriteConfig(&b, []Trigger{wallet}); e != nil {
+		t.Fatal("writer failed:", e)
+	}
+	expected := "logdir /data\npeers\n\t\tone\n\t\ttwo\nctl\n\tdatadir /ctl\n\twallet\nnode\n"
+	if b.String() != expected {
+		t.Errorf("writer output incorrect, got:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+
+	// what is written reads back to the same state
+	logdir, ctldir, port, peers = "", "", 0, nil
+	if _, e := LoadAllDefaults(&tc); e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	triggers, e := tc.ReadConfig(strings.NewReader(b.String()))
+	if e != nil {
+		t.Fatal("reader rejected written configuration:", e)
+	}
+	if logdir != "/data" || ctldir != "/ctl" || port != 11048 || len(peers) != 2 ||
+		len(triggers) != 1 || !sameNode(triggers[0], wallet) {
+		t.Error("written configuration did not read back to the same state")
+	}
+
+	// an emptied list is written as a name with no items
+	peers = []string{}
+	b.Reset()
+	if e = tc.WriteConfig(&b, nil); e != nil || !strings.Contains(b.String(), "peers\nctl") {
+		t.Error("writer did not record emptied list:", b.String())
+	}
+
+	// values that cannot be represented
+	logdir = "two\nlines"
+	if e = tc.WriteConfig(&b, nil); e == nil {
+		t.Error("writer accepted value with line break")
+	}
+
+	// nested Commands are written after the Command they are in, named by their path, and read back
+	var walletdir, label string
+	tn := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Commands{
+			{"wallet", Brief{"brief"},
+				Var{"walletdir", Brief{"brief"}, Slot{&walletdir}},
+				Commands{
+					{"account", Brief{"brief"},
+						Commands{
+							{"create", Brief{"brief"},
+								Var{"label", Brief{"brief"}, Slot{&label}},
+								MakeTestHandler(),
+							},
+						},
+					},
+					{"send", Brief{"brief"}, MakeTestHandler()},
+				},
+			},
+		},
+	}
+	if e = tn.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	walletdir, label = "/w", "main"
+	b.Reset()
+	expected = "wallet\n\twalletdir /w\nwallet/account\nwallet/account/create\n\tlabel main\nwallet/send\n"
+	if e = tn.WriteConfig(&b, nil); e != nil || b.String() != expected {
+		t.Errorf("writer output for nested Commands incorrect, got:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+	walletdir, label = "", ""
+	if _, e = tn.ReadConfig(strings.NewReader(strings.ToUpper(b.String()))); e != nil ||
+		walletdir != "/W" || label != "MAIN" {
+		t.Error("written nested configuration did not read back to the same state", e)
+	}
+	for _, x := range []string{
+		// unknown nested Command
+		"wallet/nothere",
+		// item of a nested Command under the Command it is in
+		"wallet\n\tlabel x",
+		// invalid name in the path
+		"wallet/acc0unt",
+	} {
+		if _, e = tn.ReadConfig(strings.NewReader(x)); e == nil {
+			t.Errorf("reader accepted invalid nested configuration %q", x)
+		}
+	}
+	_, e = tn.ReadConfig(strings.NewReader("wallet/account/create\n\tlabel\n"))
+	if e == nil || !strings.Contains(e.Error(), "wallet/account/create/label") {
+		t.Error("reader error does not show the path of the nested Var:", e)
+	}
+}
@@ -0,0 +1,411 @@
+package tri
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// runHelp is the handler of the built-in help Command, the topics are the positional operands of the Invocation.
+func runHelp(t *Tri) int {
+	var topics []string
+	if inv := t.Invocation(); inv != nil {
+		topics = inv.Args
+	}
+	return showHelp(t, topics...)
+}
+
+// showHelp writes the help for the topics to stdout, or if there is an error, writes it to stderr and returns 1.
+func showHelp(t *Tri, topics ...string) int {
+	if e := t.Help(os.Stdout, topics...); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		return 1
+	}
+	return 0
+}
+
+// Help writes the help text generated from the Brief, Usage, Help, Examples, Group and Short elements of the declaration. Help text is rendered with RenderHelp in the HelpStyle.
+//
+// Without a topic it shows the name, Version and Brief of the application, its Commands and the Vars and Triggers at its root. Given the name of a Command, it shows its Vars and Triggers grouped by their Group. Given the name of a Var or Trigger, it shows its Help text, default value, or that it is Required, its Allowed values, range and Sentinels, and how it appears in the configuration file. Commands nested in other Commands are named by their path, as in commandname/subcommandname, and items inside a Command can be named as commandname/name, otherwise every item with the name is shown.
+func (r *Tri) Help(w io.Writer, topic ...string) error {
+	if len(topic) < 1 {
+		r.helpOverview(w)
+		return nil
+	}
+	for i, t := range topic {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if e := r.helpTopic(w, t); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// helpTopic writes the help for a Command, Var or Trigger named in a help topic.
+func (r *Tri) helpTopic(w io.Writer, topic string) error {
+	if chain := r.commandAt(topic); chain != nil {
+		r.helpCommand(w, chain)
+		return nil
+	}
+	if i := strings.LastIndexByte(topic, '/'); i >= 0 {
+		chain := r.commandAt(topic[:i])
+		if chain == nil {
+			return fmt.Errorf("no command named '%s'", topic[:i])
+		}
+		item := itemNamed(chain[len(chain)-1], topic[i+1:])
+		if item == nil {
+			return fmt.Errorf("no command or name '%s' in command %s", topic[i+1:], commandPath(chain))
+		}
+		r.helpItem(w, chain, item)
+		return nil
+	}
+	found := false
+	if item := itemNamed(*r, topic); item != nil {
+		r.helpItem(w, nil, item)
+		found = true
+	}
+	for _, chain := range r.commandChains() {
+		if item := itemNamed(chain[len(chain)-1], topic); item != nil {
+			if found {
+				fmt.Fprintln(w)
+			}
+			r.helpItem(w, chain, item)
+			found = true
+		}
+	}
+	if !found {
+		return errors.New("no command or name '" + topic + "' found, try 'help'")
+	}
+	return nil
+}
+
+// helpOverview writes the help for the application.
+func (r *Tri) helpOverview(w io.Writer) {
+	R := *r
+	fmt.Fprintf(w, "%s %s - %s\n", nameOf(R), versionString(R), stringOf(R, Brief{}))
+	fmt.Fprintf(w, "\nusage: %s [options] [command] [command options]\n", nameOf(R))
+	helpCommands(w, r.commands())
+	helpItems(w, R)
+	fmt.Fprintf(w, "\nrun '%s help <command>' or '%s help <name>' for more detail\n", nameOf(R), nameOf(R))
+}
+
+// helpCommand writes the help for the last Command in a chain of Commands nested in each other, outermost first, as in Invocation.Path.
+func (r *Tri) helpCommand(w io.Writer, chain []Command) {
+	c := chain[len(chain)-1]
+	name := strings.Replace(commandPath(chain), "/", " ", -1)
+	if s, ok := shortOf(c); ok {
+		name += " (" + string(s) + ")"
+	}
+	fmt.Fprintf(w, "%s %s - %s\n", nameOf(*r), name, stringOf(c, Brief{}))
+	u := stringOf(c, Usage{})
+	if u == "" {
+		u = r.commandUsage(chain)
+	}
+	fmt.Fprintf(w, "\nusage: %s\n", u)
+	if h := stringOf(c, Help{}); h != "" {
+		fmt.Fprintf(w, "\n%s\n", RenderHelp(h, HelpWidth, useANSI(w)))
+	}
+	helpCommands(w, commandsOf(c))
+	helpArgs(w, c)
+	helpItems(w, c)
+	helpExamples(w, c, c)
+}
+
+// commandUsage returns a usage line for the last Command in a chain of Commands nested in each other, constructed from their names, the Commands inside it and its Args.
+func (r *Tri) commandUsage(chain []Command) string {
+	c := chain[len(chain)-1]
+	u := nameOf(*r) + " [options] " + strings.Replace(commandPath(chain), "/", " ", -1) + " [options]"
+	if len(commandsOf(c)) > 0 {
+		u += " <command> [command options]"
+	}
+	for _, a := range argsOf(c) {
+		u += " " + argUsage(a)
+	}
+	return u
+}
+
+// helpArgs writes the usage and Brief of the Args of a Command, with their Allowed values and the Default of those that are Optional, if it has any.
+func helpArgs(w io.Writer, c Command) {
+	args := argsOf(c)
+	if len(args) < 1 {
+		return
+	}
+	fmt.Fprintln(w, "\narguments:")
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	for _, a := range args {
+		brief := stringOf(a, Brief{})
+		if allowed := allowedStrings(a); allowed != nil {
+			brief += " (one of: " + strings.Join(allowed, ", ") + ")"
+		}
+		if hasFlag(a, Optional{}) && hasFlag(a, Default{}) {
+			brief += " (default: " + formatVar(Var(a), defaultValue(Var(a))) + ")"
+		}
+		fmt.Fprintf(tw, "\t%s\t%s\n", argUsage(a), brief)
+	}
+	tw.Flush()
+}
+
+// helpCommands writes the names, Shorts and Briefs of a Commands, if it has any.
+func helpCommands(w io.Writer, cc Commands) {
+	if len(cc) < 1 {
+		return
+	}
+	fmt.Fprintln(w, "\ncommands:")
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	for _, c := range cc {
+		name := nameOf(c)
+		if s, ok := shortOf(c); ok {
+			name += ", " + string(s)
+		}
+		fmt.Fprintf(tw, "\t%s\t%s\n", name, stringOf(c, Brief{}))
+	}
+	tw.Flush()
+}
+
+// helpItem writes the help for a Var or Trigger, found in the last of the given chain of Commands, or at the root if it is empty.
+func (r *Tri) helpItem(w io.Writer, chain []Command, item interface{}) {
+	var node []interface{}
+	kind := "Var"
+	switch x := item.(type) {
+	case Var:
+		node = x
+	case Trigger:
+		node, kind = x, "Trigger"
+	}
+	path := strings.ToLower(nameOf(node))
+	if len(chain) > 0 {
+		path = strings.ToLower(commandPath(chain)) + "/" + path
+	}
+	fmt.Fprintf(w, "%s %s - %s\n\n\t%s\n", kind, path, stringOf(node, Brief{}), usageOf(item))
+	if h := stringOf(node, Help{}); h != "" {
+		fmt.Fprintf(w, "\n%s\n", RenderHelp(h, HelpWidth, useANSI(w)))
+	}
+	fmt.Fprintln(w)
+	if v, ok := item.(Var); ok {
+		if hasFlag(v, Required{}) {
+			fmt.Fprintln(w, "required: it has no default and must be given a value")
+		} else {
+			fmt.Fprintf(w, "default: %s\n", formatVar(v, defaultValue(v)))
+		}
+		if allowed := allowedStrings(v); allowed != nil {
+			fmt.Fprintf(w, "allowed values: %s\n", strings.Join(allowed, ", "))
+		}
+		if i := indexOf(v, Address{}); i >= 0 && len(v[i].(Address)) == 1 {
+			fmt.Fprintf(w, "default port: %v, added to addresses given without one\n", v[i].(Address)[0])
+		}
+		if i := indexOf(v, URL{}); i >= 0 && len(v[i].(URL)) > 0 {
+			schemes := make([]string, len(v[i].(URL)))
+			for j, x := range v[i].(URL) {
+				schemes[j] = strings.ToLower(x.(string))
+			}
+			fmt.Fprintf(w, "URL schemes: %s\n", strings.Join(schemes, ", "))
+		}
+		if r := rangeString(v); r != "" {
+			fmt.Fprintf(w, "range: %s\n", r)
+		}
+		if values, names := sentinelPairs(v); len(names) > 0 {
+			special := make([]string, len(names))
+			for i, n := range names {
+				special[i] = fmt.Sprintf("%s (%v)", n, values[i])
+			}
+			fmt.Fprintf(w, "special values: %s\n", strings.Join(special, ", "))
+		}
+		if sameNode(v, r.dataDirVar()) {
+			fmt.Fprintln(w, "configuration file: not stored, it is kept inside this directory")
+		} else {
+			fmt.Fprintf(w, "configuration file: %s (in %s)\n", path, r.ConfigFile())
+		}
+	} else if t, ok := item.(Trigger); ok && isDefaultOn(t) {
+		fmt.Fprintln(w, "on by default, naming it disables it")
+		fmt.Fprintf(w, "configuration file: %s (in %s)\n", path, r.ConfigFile())
+	}
+	helpExamples(w, item, node)
+}
+
+// helpItems writes the Vars and Triggers of a container, those with no Group first, followed by each Group in the order it first appears.
+func helpItems(w io.Writer, container []interface{}) {
+	var groups []string
+	byGroup := make(map[string][]interface{})
+	for _, x := range container {
+		var node []interface{}
+		switch y := x.(type) {
+		case Var:
+			node = y
+		case Trigger:
+			node = y
+		default:
+			continue
+		}
+		g := stringOf(node, Group{})
+		if _, ok := byGroup[g]; !ok {
+			groups = append(groups, g)
+		}
+		byGroup[g] = append(byGroup[g], x)
+	}
+	if len(groups) < 1 {
+		return
+	}
+	if _, ok := byGroup[""]; ok {
+		for i, g := range groups {
+			if g == "" {
+				groups = append(append([]string{""}, groups[:i]...), groups[i+1:]...)
+				break
+			}
+		}
+	}
+	for _, g := range groups {
+		if g == "" {
+			fmt.Fprintln(w, "\noptions:")
+		} else {
+			fmt.Fprintf(w, "\n%s options:\n", g)
+		}
+		tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+		for _, x := range byGroup[g] {
+			fmt.Fprintf(tw, "\t%s\t%s\n", usageOf(x), briefOf(x))
+		}
+		tw.Flush()
+	}
+}
+
+// helpExamples writes the Examples of a Tri node, if it has any. An empty example is replaced by the generated usage of the node.
+func helpExamples(w io.Writer, item interface{}, node []interface{}) {
+	for _, x := range node {
+		if ex, ok := x.(Examples); ok {
+			fmt.Fprintln(w, "\nexamples:")
+			tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+			for i := 0; i+1 < len(ex); i += 2 {
+				example, _ := ex[i].(string)
+				if example == "" {
+					example = usageOf(item)
+				}
+				explainer, _ := ex[i+1].(string)
+				fmt.Fprintf(tw, "\t%s\t%s\n", example, explainer)
+			}
+			tw.Flush()
+		}
+	}
+}
+
+// rangeString describes the Min and Max of a Var, or returns an empty string if it has neither.
+func rangeString(v Var) string {
+	min, hasMin := bound(v, Min{})
+	max, hasMax := bound(v, Max{})
+	switch {
+	case hasMin && hasMax:
+		return fmt.Sprintf("%d to %d", min, max)
+	case hasMin:
+		return fmt.Sprintf("%d or more", min)
+	case hasMax:
+		return fmt.Sprintf("%d or less", max)
+	}
+	return ""
+}
+
+// usageOf returns the Usage of a Var or Trigger, or if it has none, one constructed from its name and Short, and for Vars other than bools that default to false, the default value as an example.
+func usageOf(item interface{}) string {
+	var node []interface{}
+	switch x := item.(type) {
+	case Var:
+		node = x
+	case Trigger:
+		node = x
+	}
+	if u := stringOf(node, Usage{}); u != "" {
+		return u
+	}
+	u := "--" + strings.ToLower(nameOf(node))
+	if s, ok := shortOf(node); ok {
+		u = "-" + string(s) + ", " + u
+	}
+	if v, ok := item.(Var); ok && !(isBool(v) && defaultValue(v) == false) {
+		u += "=" + formatVar(v, defaultValue(v))
+	}
+	return u
+}
+
+// stringOf returns the string inside the first element of a Tri node that has the same type as the example given, such as Brief{}, or an empty string if there is none.
+func stringOf(node []interface{}, example interface{}) string {
+	t := reflect.TypeOf(example)
+	for _, x := range node {
+		if reflect.TypeOf(x) == t {
+			if v := reflect.ValueOf(x); v.Len() > 0 {
+				s, _ := v.Index(0).Interface().(string)
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// versionString formats the Version of a Tri as a semver string.
+func versionString(t Tri) string {
+	for _, x := range t {
+		if v, ok := x.(Version); ok && len(v) >= 3 {
+			s := fmt.Sprintf("v%v.%v.%v", v[0], v[1], v[2])
+			if len(v) > 3 {
+				s += fmt.Sprintf("-%v", v[3])
+			}
+			return s
+		}
+	}
+	return ""
+}
+
+// briefOf returns the Brief text of a Var or Trigger, marked if it is a Required Var.
+func briefOf(item interface{}) string {
+	switch x := item.(type) {
+	case Var:
+		if hasFlag(x, Required{}) {
+			return stringOf(x, Brief{}) + " (required)"
+		}
+		return stringOf(x, Brief{})
+	case Trigger:
+		return stringOf(x, Brief{})
+	}
+	return ""
+}
+
+// commands returns the Commands of the Tri.
+func (r *Tri) commands() Commands {
+	return commandsOf(*r)
+}
+
+// commandChains returns every Command in the Tri, nested ones following the Command they are in, each with the Commands it is nested in, outermost first, as in Invocation.Path.
+func (r *Tri) commandChains() (chains [][]Command) {
+	var walk func(cc Commands, outer []Command)
+	walk = func(cc Commands, outer []Command) {
+		for _, c := range cc {
+			chain := append(append([]Command{}, outer...), c)
+			chains = append(chains, chain)
+			walk(commandsOf(c), chain)
+		}
+	}
+	walk(r.commands(), nil)
+	return
+}
+
+// commandAt returns the Command named by a path of Command names separated by slashes, ignoring case, with the Commands it is nested in, outermost first, or nil if there is none.
+func (r *Tri) commandAt(path string) (chain []Command) {
+	cc := r.commands()
+	for _, name := range strings.Split(path, "/") {
+		var found Command
+		for _, c := range cc {
+			if strings.EqualFold(nameOf(c), name) {
+				found = c
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		chain = append(chain, found)
+		cc = commandsOf(found)
+	}
+	return chain
+}
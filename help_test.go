@@ -0,0 +1,212 @@
+package tri
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpOutput(t *testing.T) {
+	var port int
+	var ctldir string
+	th := Tri{"appname", Brief{"the brief of the app"}, Version{0, 1, 1, "alpha"},
+		Var{"port", Short{'p'}, Brief{"port to listen on"}, Help{"Some help text"},
+			Default{11048}, Slot{&port}},
+		Trigger{"wallet", Brief{"run the wallet"}, DefaultOn{}, MakeTestHandler()},
+		Commands{
+			{"ctl", Short{'c'}, Brief{"control the node"}, Help{"Help for ctl"},
+				Examples{"ctl --datadir=/x", "use another directory"},
+				Var{"datadir", Brief{"ctl data"}, Group{"paths"}, Override{}, Slot{&ctldir}},
+				Var{"level", Brief{"log level"}, Group{"logging"}, Slot{&port}},
+				Trigger{"reset", Brief{"reset things"}, MakeTestHandler()},
+				MakeTestHandler(),
+			},
+		},
+	}
+	if e := th.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	help := func(topic ...string) string {
+		var b strings.Builder
+		if e := th.Help(&b, topic...); e != nil {
+			return "error: " + e.Error()
+		}
+		return b.String()
+	}
+	contains := func(s string, parts ...string) bool {
+		for _, p := range parts {
+			if !strings.Contains(s, p) {
+				t.Logf("%q not found in:\n%s", p, s)
+				return false
+			}
+		}
+		return true
+	}
+
+	// overview shows name, version, brief, commands and root options
+	if !contains(help(), "appname v0.1.1-alpha - the brief of the app",
+		"ctl, c", "control the node", "help", "-p, --port=11048", "port to listen on",
+		"--wallet", "--init") {
+		t.Error("overview incomplete")
+	}
+
+	// command page shows items grouped by Group, ungrouped first
+	h := help("ctl")
+	if !contains(h, "appname ctl (c) - control the node", "Help for ctl",
+		"options:", "--reset", "paths options:", "--datadir=", "logging options:",
+		"examples:", "use another directory") {
+		t.Error("command help incomplete")
+	}
+	if !(strings.Index(h, "\noptions:") < strings.Index(h, "paths options:") &&
+		strings.Index(h, "paths options:") < strings.Index(h, "logging options:")) {
+		t.Error("command help groups out of order")
+	}
+
+	// var page shows help, default and configuration path
+	if !contains(help("port"), "Var port - port to listen on", "Some help text",
+		"default: 11048", "configuration file: port") {
+		t.Error("var help incomplete")
+	}
+	if !contains(help("ctl/datadir"), "Var ctl/datadir", "configuration file: ctl/datadir") {
+		t.Error("command var help incomplete")
+	}
+	if !contains(help("datadir"), "Var datadir", "not stored", "Var ctl/datadir") {
+		t.Error("help for a name did not show every item with it")
+	}
+	if !contains(help("wallet"), "Trigger wallet", "on by default") {
+		t.Error("trigger help incomplete")
+	}
+
+	// unknown topics
+	for _, x := range []string{"nothere", "nothere/port", "ctl/nothere"} {
+		if !strings.HasPrefix(help(x), "error: ") {
+			t.Error("help accepted unknown topic", x)
+		}
+	}
+
+	// help is reserved
+	tr := Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Commands{{"help", Brief{"brief"}, MakeTestHandler()}},
+	}
+	if e := tr.Validate(); e == nil {
+		t.Error("validator accepted a Command using the name of the built-in help")
+	}
+	// DefaultCommand may be the built-in help
+	tr = Tri{"appname", Brief{"brief"}, Version{0, 1, 1}, DefaultCommand{"help"}}
+	if e := tr.Validate(); e != nil {
+		t.Error("validator rejected help as DefaultCommand:", e)
+	}
+
+	// nested Commands are listed in the help of the Command they are in, and named by their path
+	tn := Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Commands{
+			{"wallet", Brief{"manage the wallet"},
+				Commands{
+					{"account", Short{'a'}, Brief{"manage accounts"},
+						Var{"label", Brief{"account label"}, Slot{&ctldir}},
+						MakeTestHandler(),
+					},
+				},
+			},
+		},
+	}
+	if e := tn.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	th = tn
+	if !contains(help("wallet"), "appname wallet - manage the wallet", "commands:", "account, a", "manage accounts") {
+		t.Error("command help does not list nested Commands")
+	}
+	if !contains(help("wallet/account"), "appname wallet account (a) - manage accounts", "--label") {
+		t.Error("nested command help incomplete")
+	}
+	if !contains(help("wallet/account/label"), "Var wallet/account/label", "configuration file: wallet/account/label") {
+		t.Error("nested command var help incomplete")
+	}
+	if !contains(help("label"), "Var wallet/account/label") {
+		t.Error("help for a name did not find it in a nested Command")
+	}
+	for _, x := range []string{"account", "wallet/nothere", "wallet/account/nothere"} {
+		if !strings.HasPrefix(help(x), "error: ") {
+			t.Error("help accepted unknown topic", x)
+		}
+	}
+
+	// Args are shown in the generated usage line and listed with their Briefs
+	var files []string
+	ta := Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Commands{
+			{"send", Brief{"send coins"},
+				Arg{"address", Brief{"where to send"}, Slot{&ctldir}},
+				Arg{"files", Brief{"attachments"}, Optional{}, Variadic{}, Default{[]string{"a", "b"}}, Slot{&files}},
+				MakeTestHandler(),
+			},
+		},
+	}
+	if e := ta.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	th = ta
+	if !contains(help("send"), "usage: appname [options] send [options] <address> [files...]",
+		"arguments:", "<address>", "where to send", "[files...]", "attachments (default: a,b)") {
+		t.Error("command help does not show Args")
+	}
+	th = tn
+	if !contains(help("wallet"), "usage: appname [options] wallet [options] <command> [command options]") {
+		t.Error("command help does not show generated usage for nested Commands")
+	}
+
+	// Required Vars are marked in lists and have no default shown
+	var user string
+	th = Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"rpcuser", Brief{"rpc user name"}, Required{}, Slot{&user}},
+	}
+	if !contains(help(), "rpc user name (required)") {
+		t.Error("help does not mark Required Vars")
+	}
+	if out := help("rpcuser"); !contains(out, "required: it has no default") || contains(out, "default: ") {
+		t.Error("help for a Required Var shows a default")
+	}
+
+	// Allowed values are listed for Vars and Args
+	var network, kind string
+	th = Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"network", Brief{"brief"}, Allowed{"mainnet", "testnet"}, Default{"mainnet"}, Slot{&network}},
+		Commands{
+			{"address", Brief{"brief"},
+				Arg{"kind", Brief{"address kind"}, Allowed{"legacy", "segwit"}, Slot{&kind}},
+				MakeTestHandler(),
+			},
+		},
+	}
+	if !contains(help("network"), "default: mainnet", "allowed values: mainnet, testnet") {
+		t.Error("help for a Var does not list its Allowed values")
+	}
+	if !contains(help("address"), "address kind (one of: legacy, segwit)") {
+		t.Error("help for a Command does not list the Allowed values of its Args")
+	}
+
+	// Sentinels are shown by name, with the range of values
+	var threads int
+	th = Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"genthreads", Brief{"brief"}, Min{1}, Max{64}, Sentinels{-1, "all"}, Default{-1}, Slot{&threads}},
+	}
+	if !contains(help("genthreads"), "--genthreads=all", "default: all", "range: 1 to 64", "special values: all (-1)") {
+		t.Error("help for a Var does not show its range and Sentinels")
+	}
+
+	// Address Defaults are shown in normal form, with the default port
+	var listen string
+	th = Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"listen", Brief{"brief"}, Address{11047}, Default{"::1"}, Slot{&listen}},
+	}
+	if !contains(help("listen"), "default: [::1]:11047", "default port: 11047") {
+		t.Error("help for an Address Var does not show its default port")
+	}
+	var proxy string
+	th = Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		Var{"proxy", Brief{"brief"}, URL{"SOCKS5", "http"}, Host{}, Slot{&proxy}},
+	}
+	if !contains(help("proxy"), "URL schemes: socks5, http") {
+		t.Error("help for a URL Var does not show its schemes")
+	}
+}
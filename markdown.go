@@ -0,0 +1,238 @@
+package tri
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Style selects how the markdown in Help text is rendered for display.
+type Style int
+
+const (
+	// AutoStyle uses ANSIStyle when the output is a terminal, and PlainStyle otherwise.
+	AutoStyle Style = iota
+	// PlainStyle removes all of the markdown annotations and wraps the text.
+	PlainStyle
+	// ANSIStyle represents headings, emphasis and code with ANSI codes and wraps the text.
+	ANSIStyle
+)
+
+// HelpStyle is the Style the help Command uses to render Help text. Applications may set it to override the automatic choice.
+var HelpStyle = AutoStyle
+
+// HelpWidth is the number of columns Help text is wrapped to.
+var HelpWidth = 80
+
+// ANSI codes used for each kind of styled text, each has its own code to turn it off, so they can be nested.
+const (
+	ansiBold       = "\x1b[1m"
+	ansiBoldOff    = "\x1b[22m"
+	ansiItalic     = "\x1b[3m"
+	ansiItalicOff  = "\x1b[23m"
+	ansiCode       = "\x1b[36m"
+	ansiCodeOff    = "\x1b[39m"
+	ansiHeading    = "\x1b[1;4m"
+	ansiHeadingOff = "\x1b[22;24m"
+)
+
+var (
+	headingLine  = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	listLine     = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	fenceLine    = regexp.MustCompile("^ {0,3}```")
+	ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// useANSI returns true if Help text written to w should be styled with ANSI codes. Under AutoStyle this is when w is a terminal, TERM is not dumb and NO_COLOR is not set.
+func useANSI(w io.Writer) bool {
+	switch HelpStyle {
+	case PlainStyle:
+		return false
+	case ANSIStyle:
+		return true
+	}
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, e := f.Stat()
+	return e == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// RenderHelp converts the markdown in a Help text into text wrapped to the given width, either with the annotations removed, or if ansi is true, represented with ANSI codes.
+//
+// Headings (#), emphasis (*, _, ** and __), bulleted and numbered lists, code spans (`) and fenced code blocks (```) are recognised. Paragraphs are separated by blank lines, and the lines within a paragraph are joined and rewrapped. Code blocks are indented and not wrapped.
+func RenderHelp(text string, width int, ansi bool) string {
+	var out []string
+	var para []string
+	// inList means the previous block was a list item, which are not separated by blank lines
+	inList := false
+	blank := func() {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+	flush := func() {
+		if len(para) > 0 {
+			blank()
+			out = append(out, wrap(renderInline(strings.Join(para, " "), ansi), width, "", "")...)
+			para = nil
+		}
+	}
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		switch {
+		case strings.TrimSpace(l) == "":
+			flush()
+			inList = false
+		case fenceLine.MatchString(l):
+			flush()
+			blank()
+			for i++; i < len(lines) && !fenceLine.MatchString(lines[i]); i++ {
+				code := "    " + lines[i]
+				if ansi {
+					code = "    " + ansiCode + lines[i] + ansiCodeOff
+				}
+				out = append(out, code)
+			}
+			inList = false
+		case headingLine.MatchString(l):
+			flush()
+			blank()
+			h := renderInline(headingLine.FindStringSubmatch(l)[1], ansi)
+			if ansi {
+				h = ansiHeading + h + ansiHeadingOff
+			}
+			out = append(out, h)
+			inList = false
+		case listLine.MatchString(l):
+			flush()
+			if !inList {
+				blank()
+			}
+			m := listLine.FindStringSubmatch(l)
+			item := []string{m[3]}
+			// continuation lines are indented and are not the start of another block
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], " ") &&
+				strings.TrimSpace(lines[i+1]) != "" && !listLine.MatchString(lines[i+1]) {
+				i++
+				item = append(item, strings.TrimSpace(lines[i]))
+			}
+			marker := m[2]
+			if marker == "*" || marker == "+" {
+				marker = "-"
+			}
+			indent := strings.Repeat(" ", 2+len(m[1]))
+			first := indent + marker + " "
+			out = append(out, wrap(renderInline(strings.Join(item, " "), ansi), width,
+				first, strings.Repeat(" ", len(first)))...)
+			inList = true
+		default:
+			para = append(para, strings.TrimSpace(l))
+			inList = false
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// renderInline converts the emphasis and code span annotations in a line of markdown, removing them or replacing them with ANSI codes. A backslash before an annotation character makes it literal.
+func renderInline(s string, ansi bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_#", s[i+1]) >= 0:
+			b.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '`':
+			if j := strings.IndexByte(s[i+1:], '`'); j >= 0 {
+				code := s[i+1 : i+1+j]
+				if ansi {
+					code = ansiCode + code + ansiCodeOff
+				}
+				b.WriteString(code)
+				i += j + 2
+				continue
+			}
+		case c == '*' || c == '_':
+			d := s[i : i+1]
+			on, off := ansiItalic, ansiItalicOff
+			if strings.HasPrefix(s[i:], d+d) {
+				d += d
+				on, off = ansiBold, ansiBoldOff
+			}
+			if j := closingDelimiter(s, i, d); j >= 0 {
+				inner := renderInline(s[i+len(d):j], ansi)
+				if ansi {
+					inner = on + inner + off
+				}
+				b.WriteString(inner)
+				i = j + len(d)
+				continue
+			}
+			// an unmatched delimiter is literal
+			b.WriteString(d)
+			i += len(d)
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// closingDelimiter returns the position of the delimiter that closes the emphasis opened by the one at position i, or -1 if it does not open emphasis or is not closed. Emphasis must not start or end with a space, and may not start or end inside a word, so identifiers like snake_case are left alone.
+func closingDelimiter(s string, i int, d string) int {
+	start := i + len(d)
+	if start >= len(s) || s[start] == ' ' || (i > 0 && isWordByte(s[i-1])) {
+		return -1
+	}
+	for j := start + 1; j+len(d) <= len(s); j++ {
+		if s[j:j+len(d)] != d || s[j-1] == ' ' {
+			continue
+		}
+		after := j + len(d)
+		if after < len(s) && (isWordByte(s[after]) || s[after] == d[0]) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// isWordByte returns true if the byte is part of a word, for the purpose of finding emphasis delimiters.
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// wrap splits text into lines no longer than width visible characters, the first starting with first and the rest with rest. ANSI codes are not counted in the width, and words longer than the width are not broken.
+func wrap(text string, width int, first, rest string) (lines []string) {
+	line, lineLen := first, visibleLen(first)
+	empty := true
+	for _, word := range strings.Fields(text) {
+		wl := visibleLen(word)
+		if !empty && lineLen+1+wl > width {
+			lines = append(lines, line)
+			line, lineLen, empty = rest, visibleLen(rest), true
+		}
+		if !empty {
+			line += " "
+			lineLen++
+		}
+		line += word
+		lineLen += wl
+		empty = false
+	}
+	return append(lines, line)
+}
+
+// visibleLen returns the number of characters in a string that will be displayed, not counting ANSI codes.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(s, ""))
+}
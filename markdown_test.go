@@ -0,0 +1,71 @@
+package tri
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHelp(t *testing.T) {
+	md := "# Heading\n\nSome *emphasis*, **strong** and `code`\nin a paragraph with snake_case and 2 * 3.\n\n" +
+		"- first item\n  continued\n* second __item__\n1. numbered\n\n```\nverbatim *text*\n```\n\\*literal\\*"
+
+	// plain removes the annotations
+	plain := RenderHelp(md, 80, false)
+	expected := "Heading\n\n" +
+		"Some emphasis, strong and code in a paragraph with snake_case and 2 * 3.\n\n" +
+		"  - first item continued\n  - second item\n  1. numbered\n\n" +
+		"    verbatim *text*\n\n*literal*"
+	if plain != expected {
+		t.Errorf("plain rendering incorrect, got:\n%s\nexpected:\n%s", plain, expected)
+	}
+
+	// ansi replaces them with codes
+	ansi := RenderHelp(md, 80, true)
+	for _, x := range []string{
+		ansiHeading + "Heading" + ansiHeadingOff,
+		ansiItalic + "emphasis" + ansiItalicOff,
+		ansiBold + "strong" + ansiBoldOff,
+		ansiCode + "code" + ansiCodeOff,
+		ansiBold + "item" + ansiBoldOff,
+		"snake_case",
+	} {
+		if !strings.Contains(ansi, x) {
+			t.Errorf("ansi rendering does not contain %q:\n%s", x, ansi)
+		}
+	}
+
+	// nested emphasis
+	if r := RenderHelp("**bold *and italic***", 80, false); r != "bold and italic" {
+		t.Error("nested emphasis not removed:", r)
+	}
+	// unmatched delimiters are literal
+	if r := RenderHelp("a * b and *c", 80, false); r != "a * b and *c" {
+		t.Error("unmatched delimiters removed:", r)
+	}
+
+	// wrapping counts visible characters and indents list items
+	long := strings.Repeat("word ", 30)
+	for _, a := range []bool{false, true} {
+		for _, l := range strings.Split(RenderHelp("**"+long+"**\n\n- "+long, 40, a), "\n") {
+			if visibleLen(l) > 40 {
+				t.Errorf("line longer than width: %q", l)
+			}
+		}
+	}
+	lines := strings.Split(RenderHelp("- "+long, 40, false), "\n")
+	if !strings.HasPrefix(lines[0], "  - word") || !strings.HasPrefix(lines[1], "    word") {
+		t.Error("list item not indented:", lines)
+	}
+
+	// style can be overridden
+	defer func(s Style) { HelpStyle = s }(HelpStyle)
+	var b strings.Builder
+	HelpStyle = AutoStyle
+	if useANSI(&b) {
+		t.Error("ansi used for output that is not a terminal")
+	}
+	HelpStyle = ANSIStyle
+	if !useANSI(&b) {
+		t.Error("ansi style not used when selected")
+	}
+}
@@ -1,83 +1,802 @@
 package tri
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// LoadAllDefaults walks a Tri and calls LoadDefaults on each one for the first step in composition of configuration
-func LoadAllDefaults(t *Tri) {
-	T := *t
-	var counter, varsfound int
-	for _, x := range T {
-		if v, ok := x.(Var); ok {
-			varsfound++
-			if LoadDefaults(&v) {
-				counter++
+// DefaultsReport is the result of loading the defaults of a Tri with LoadAllDefaults.
+type DefaultsReport struct {
+	// VarsFound is the number of Vars and Args in the Tri, and DefaultsApplied the number of those with a Default that was placed in their Slot.
+	VarsFound, DefaultsApplied int
+	// DefaultOn are the Triggers that are on by default, which run unless they are named in the configuration or CLI args, in the order they are declared.
+	DefaultOn []Trigger
+	// Errors are the Vars and Args whose Default could not be loaded.
+	Errors DeclarationErrors
+}
+
+// LoadAllDefaults walks every scope of a Tri, its root and each of its Commands, calling LoadDefaults on each Var and Arg for the first step in composition of configuration, and collecting the DefaultOn Triggers. Vars whose Default cannot be loaded do not stop the others from being loaded, every problem is in the Errors of the report, which are also returned as the error.
+func LoadAllDefaults(t *Tri) (report DefaultsReport, e error) {
+	var walk func(container []interface{}, path string)
+	walk = func(container []interface{}, path string) {
+		load := func(v Var, kind string) {
+			report.VarsFound++
+			found, e := LoadDefaults(&v)
+			if e != nil {
+				element, index := "Default", indexOf(v, Default{})
+				if slotOf(v) == nil {
+					element, index = "Slot", -1
+				}
+				report.Errors = append(report.Errors,
+					newValidationError(pathOf(path+"/"+kind, v), kind, nameOf(v), index, element, e))
+			} else if found {
+				report.DefaultsApplied++
 			}
 		}
-		if v, ok := x.(Commands); ok {
-			for _, x := range v {
-				for _, y := range x {
-					if v, ok := y.(Var); ok {
-						varsfound++
-						if LoadDefaults(&v) {
-							counter++
-						}
-					}
+		for _, x := range container {
+			switch y := x.(type) {
+			case Var:
+				load(y, "Var")
+			case Arg:
+				load(Var(y), "Arg")
+			case Trigger:
+				if isDefaultOn(y) {
+					report.DefaultOn = append(report.DefaultOn, y)
+				}
+			case Commands:
+				for _, c := range y {
+					walk(c, pathOf(path+"/Commands", c))
 				}
 			}
 		}
 	}
+	walk(*t, pathOf("", *t))
+	if len(report.Errors) > 0 {
+		return report, report.Errors
+	}
+	return report, nil
 }
 
-// LoadDefaults reads the Default (if any) in a Var, and copies the value into the Slot, returns true if there was a Default and it was filled
-func LoadDefaults(v *Var) (found bool) {
-	// First find if there is a default
-	var def Default
+// LoadDefaults reads the Default (if any) in a Var, and copies the value into the Slot, returns true if there was a Default and it was filled. An error is returned if the Var has no Slot, its Slot is not valid, or its Default cannot be placed in the Slot, in which case the Slot is not changed.
+func LoadDefaults(v *Var) (found bool, e error) {
 	V := *v
+	var slot Slot
+	var hasSlot bool
+	for _, x := range V {
+		if j, ok := x.(Slot); ok {
+			slot, hasSlot = j, true
+		}
+	}
+	if !hasSlot {
+		return false, fmt.Errorf("Var %s has no Slot to place its Default into", nameOf(V))
+	}
+	if e = slot.Validate(); e != nil {
+		return false, e
+	}
+	var def Default
 	for _, x := range V {
 		if j, ok := x.(Default); ok {
-			def = j
-			found = true
+			def, found = j, true
 		}
 	}
 	if !found {
-		return false
+		return false, nil
+	}
+	if e = def.Validate(); e != nil {
+		return false, e
 	}
-	found = false
+	to := reflect.TypeOf(slot[0]).Elem()
+	value, e := convertDefault(def[0], to)
+	if e != nil {
+		return false, e
+	}
+	if value, e = normalise(V, value); e != nil {
+		return false, e
+	}
+	// a nil Default for an interface type loses its type in an interface{}, so the typed zero is placed instead
+	val := reflect.Zero(to)
+	if value != nil {
+		val = reflect.ValueOf(value)
+	}
+	for _, x := range slot {
+		reflect.ValueOf(x).Elem().Set(val)
+	}
+	return true, nil
+}
+
+// ParseVar converts a value to the type pointed to by the Slot of a Var and places it into every pointer in the Slot.
+//
+// The value may be a string, as found in the CLI args and the configuration file, which is parsed according to the type of the Slot, or by the parse function of its Handler if it has one, or it may already be of the type the Slot points to, in which case it is copied directly. If the Handler has a validate function the value must pass it before it is placed, and if the Var has an Allowed element the value must be one of its values. A value outside the Min and Max of the Var is an error, unless it is one of its Sentinels, whose names may be given instead of their values. The values of an Address or Port Var are checked and placed in their normal form, see Address.
+func ParseVar(v *Var, value interface{}) error {
+	V := *v
 	var slot Slot
 	for _, x := range V {
 		if j, ok := x.(Slot); ok {
 			slot = j
-			found = true
 		}
 	}
-	if !found {
-		return false
+	if len(slot) < 1 {
+		return fmt.Errorf("Var %v has no Slot to place a value into", V[0])
 	}
-	for _, x := range slot {
-		switch S := x.(type) {
-		case *string:
-			s := def[0].(string)
-			*S = s
-		case *int:
-			s := def[0].(int)
-			*S = s
-		case *uint32:
-			s := def[0].(uint32)
-			*S = s
-		case *float64:
-			s := def[0].(float64)
-			*S = s
-		case *[]string:
-			s := def[0].([]string)
-			*S = s
-		case *time.Duration:
-			s := def[0].(time.Duration)
-			*S = s
-		default:
-			panic("unrecognised type found in slot")
-		}
-	}
-	return true
+	_, _, validate := valueHandlers(V)
+	out, e := convertValue(V, value)
+	if e != nil {
+		return e
+	}
+	if out == nil {
+		return fmt.Errorf("Var %v cannot be set to nil", V[0])
+	}
+	if out, e = normalise(V, out); e != nil {
+		return e
+	}
+	if validate != nil {
+		if e := validate(out); e != nil {
+			return e
+		}
+	}
+	if e := checkAllowed(V, out); e != nil {
+		return e
+	}
+	if e := checkBounds(V, out); e != nil {
+		return e
+	}
+	val := reflect.ValueOf(out)
+	for i, x := range slot {
+		p := reflect.ValueOf(x).Elem()
+		if !val.Type().AssignableTo(p.Type()) {
+			return fmt.Errorf(
+				"value of type %v cannot be placed in Slot %d of type %v", val.Type(), i, p.Type())
+		}
+		p.Set(val)
+	}
+	return nil
+}
+
+// AppendVar adds the items in a value to the list in the Slot of a Var with a []string Slot, for Vars that may be given more than once. The value is parsed as by ParseVar, so a string is split at commas, dropping empty items, and an empty string or list clears the list instead. For any other type of Slot it is the same as ParseVar.
+func AppendVar(v *Var, value interface{}) error {
+	current, ok := slotValue(*v).([]string)
+	if !ok {
+		return ParseVar(v, value)
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return ParseVar(v, []string{})
+	}
+	out, e := convertValue(*v, value)
+	if e != nil {
+		return e
+	}
+	items, ok := out.([]string)
+	if !ok {
+		return fmt.Errorf("value of type %T cannot be appended to Var %v", out, (*v)[0])
+	}
+	if len(items) == 0 {
+		return ParseVar(v, []string{})
+	}
+	// the current list may share its array with the Default, so it is copied rather than appended to
+	return ParseVar(v, append(append([]string{}, current...), items...))
+}
+
+// convertValue converts a string to the type pointed to by the Slot of a Var, with the parse function of its Handler if it has one, or if it is the name of one of its Sentinels, to its value, and returns any other value unchanged.
+func convertValue(v Var, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	if value, ok := sentinelNamed(v, s); ok {
+		return value, nil
+	}
+	if parse, _, _ := valueHandlers(v); parse != nil {
+		return parse(s)
+	}
+	slot := slotOf(v)
+	if _, ok := slot.(*float64); ok {
+		return parseDecimal(s, precisionOf(v))
+	}
+	return parseValue(slot, s)
+}
+
+// parseValue converts a string to the type that a Slot element points to.
+func parseValue(slot interface{}, s string) (interface{}, error) {
+	switch slot.(type) {
+	case *bool:
+		switch strings.ToLower(s) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("'%s' is not a boolean, use true or false", s)
+	case *string:
+		return s, nil
+	case *int:
+		i, e := strconv.Atoi(s)
+		if e != nil {
+			return nil, fmt.Errorf("'%s' is not an integer", s)
+		}
+		return i, nil
+	case *uint32:
+		return parseSize(s)
+	case *float64:
+		return parseDecimal(s, DefaultPrecision)
+	case *[]string:
+		// empty items are dropped, so an empty string is an empty list
+		items := []string{}
+		for _, x := range strings.Split(s, ",") {
+			if x != "" {
+				items = append(items, x)
+			}
+		}
+		return items, nil
+	case *time.Duration:
+		d, e := time.ParseDuration(s)
+		if e != nil {
+			return nil, fmt.Errorf("'%s' is not a duration", s)
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unrecognised type %v found in slot", reflect.TypeOf(slot))
+}
+
+// DefaultPrecision is the number of decimal places kept in float64 Vars without a Precision, the precision of currency amounts in pod, where the smallest unit is 1/100000000 (one satoshi).
+const DefaultPrecision = 8
+
+// MaxPrecision is the largest number of decimal places that can be given in a Precision.
+const MaxPrecision = 17
+
+// decimalNumber matches a number in plain decimal notation, with an optional sign, fraction and exponent.
+var decimalNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// parseDecimal converts a string to a float64, truncating it to the given number of decimal places, unless it is -1. Only plain decimal notation is accepted, not the hexadecimal, infinite and NaN forms strconv.ParseFloat also reads. The truncation is done on the exact decimal value of the string before it is converted to binary, so the result is the closest float64 to the truncated decimal.
+func parseDecimal(s string, places int) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	f, e := strconv.ParseFloat(s, 64)
+	if e != nil || !decimalNumber.MatchString(s) {
+		return nil, fmt.Errorf("'%s' is not a floating point number", s)
+	}
+	if places < 0 {
+		return f, nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a finite decimal number", s)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	// Quo truncates towards zero, dropping the excess places
+	n := new(big.Int).Quo(new(big.Int).Mul(r.Num(), scale), r.Denom())
+	f, _ = new(big.Rat).SetFrac(n, scale).Float64()
+	return f, nil
+}
+
+// precisionOf returns the number of decimal places kept in a float64 Var, from its Precision or DefaultPrecision.
+func precisionOf(v []interface{}) int {
+	for _, x := range v {
+		if p, ok := x.(Precision); ok && len(p) == 1 {
+			if n, ok := p[0].(int); ok {
+				return n
+			}
+		}
+	}
+	return DefaultPrecision
+}
+
+// maxPort is the largest network port number.
+const maxPort = 65535
+
+// normalise returns the value for a Var in its normal form, if the Var is an Address or Port, checking that it is valid. Each address in a list is normalised and those that repeat an earlier one are removed. The URLs of a URL Var are checked, but returned unchanged, as is any other value.
+func normalise(v []interface{}, value interface{}) (interface{}, error) {
+	if i := indexOf(v, Address{}); i >= 0 {
+		var port int
+		if a := v[i].(Address); len(a) == 1 {
+			port, _ = a[0].(int)
+		}
+		switch x := value.(type) {
+		case string:
+			if x == "" {
+				return x, nil
+			}
+			return normaliseAddress(x, port)
+		case []string:
+			out := make([]string, 0, len(x))
+			for _, item := range x {
+				a, e := normaliseAddress(item, port)
+				if e != nil {
+					return nil, e
+				}
+				if indexOfString(out, a) < 0 {
+					out = append(out, a)
+				}
+			}
+			return out, nil
+		}
+	}
+	if i := indexOf(v, URL{}); i >= 0 {
+		urls, _ := value.([]string)
+		if s, ok := value.(string); ok && s != "" {
+			urls = []string{s}
+		}
+		for _, u := range urls {
+			if e := checkURL(u, v[i].(URL), hasFlag(v, Host{})); e != nil {
+				return nil, e
+			}
+		}
+	}
+	if indexOf(v, Port{}) >= 0 {
+		switch x := value.(type) {
+		case string:
+			if x == "" {
+				return x, nil
+			}
+			p, e := parsePort(x)
+			if e != nil {
+				return nil, e
+			}
+			return strconv.Itoa(p), nil
+		case int:
+			if x < 1 || x > maxPort {
+				return nil, fmt.Errorf("port %d is not between 1 and %d", x, maxPort)
+			}
+		}
+	}
+	return value, nil
+}
+
+// normaliseAddress checks a network address in the form host:port, where the host may be a name, an IPv4 address or an IPv6 address in square brackets, and returns it with the host in lower case and the given port added if it has none. An IPv6 address without a port may be given without brackets. The host may be empty, meaning every interface, only if the port is given.
+func normaliseAddress(s string, port int) (string, error) {
+	host, p, e := net.SplitHostPort(s)
+	if e != nil {
+		host = s
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		} else if strings.ContainsAny(host, "[]") || strings.Count(host, ":") == 1 {
+			return "", fmt.Errorf("'%s' is not a valid address: %v", s, e)
+		}
+		if port == 0 {
+			return "", fmt.Errorf("address '%s' has no port", s)
+		}
+		if host == "" {
+			return "", fmt.Errorf("'%s' is not a valid address, it has no host", s)
+		}
+		p = strconv.Itoa(port)
+	}
+	n, e := parsePort(p)
+	if e != nil {
+		return "", fmt.Errorf("'%s' is not a valid address: %v", s, e)
+	}
+	host = strings.ToLower(host)
+	if strings.HasPrefix(s, "[") && !strings.Contains(host, ":") {
+		return "", fmt.Errorf("'%s' is not a valid address, only IPv6 addresses are put in brackets", s)
+	}
+	if strings.Contains(host, ":") {
+		if net.ParseIP(host) == nil {
+			return "", fmt.Errorf("'%s' is not a valid address, '%s' is not an IPv6 address", s, host)
+		}
+	} else {
+		for _, c := range host {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_') {
+				return "", fmt.Errorf("'%s' is not a valid address, the host contains '%c'", s, c)
+			}
+		}
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n)), nil
+}
+
+// checkURL returns an error if a string is not a URL with a scheme, or its scheme is not one of those given, if any are, or if host is true and it has no host. A port in the URL must be between 1 and 65535.
+func checkURL(s string, schemes URL, host bool) error {
+	u, e := url.Parse(s)
+	if e != nil {
+		return fmt.Errorf("'%s' is not a valid URL: %v", s, errors.Unwrap(e))
+	}
+	if u.Scheme == "" {
+		return fmt.Errorf("URL '%s' has no scheme, as in http://", s)
+	}
+	if len(schemes) > 0 {
+		names := make([]string, len(schemes))
+		found := false
+		for i, x := range schemes {
+			names[i] = strings.ToLower(x.(string))
+			found = found || names[i] == u.Scheme
+		}
+		if !found {
+			return fmt.Errorf("URL '%s' has the scheme %s, it must be one of %s", s, u.Scheme, strings.Join(names, ", "))
+		}
+	}
+	if host && u.Hostname() == "" {
+		return fmt.Errorf("URL '%s' has no host", s)
+	}
+	if p := u.Port(); p != "" {
+		if _, e := parsePort(p); e != nil {
+			return fmt.Errorf("URL '%s' has an invalid port: %v", s, e)
+		}
+	}
+	return nil
+}
+
+// parsePort converts a string to a network port number, between 1 and 65535.
+func parsePort(s string) (int, error) {
+	n, e := strconv.Atoi(s)
+	if e != nil || s[0] == '+' || s[0] == '-' {
+		return 0, fmt.Errorf("port '%s' is not a number", s)
+	}
+	if n < 1 || n > maxPort {
+		return 0, fmt.Errorf("port %d is not between 1 and %d", n, maxPort)
+	}
+	return n, nil
+}
+
+// isSlot returns true if the Slot of a Var or Arg holds pointers of the same type as one of the examples.
+func isSlot(v []interface{}, examples ...interface{}) bool {
+	t := reflect.TypeOf(slotOf(Var(v)))
+	for _, x := range examples {
+		if t == reflect.TypeOf(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeSuffixes are the multipliers understood in uint32 values, largest first, so that formatSize uses the largest that divides a value exactly.
+var sizeSuffixes = []struct {
+	suffix     string
+	multiplier uint64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+// parseSize converts a string to a uint32, which may have a KiB, MiB or GiB suffix (or just K, M or G, in any case) multiplying it by a power of 1024, as most uint32 values are sizes in bytes.
+func parseSize(s string) (interface{}, error) {
+	number, multiplier := strings.TrimSpace(s), uint64(1)
+	for _, x := range sizeSuffixes {
+		lower := strings.ToLower(number)
+		if strings.HasSuffix(lower, strings.ToLower(x.suffix)) {
+			number, multiplier = number[:len(number)-len(x.suffix)], x.multiplier
+			break
+		}
+		if strings.HasSuffix(lower, strings.ToLower(x.suffix[:1])) {
+			number, multiplier = number[:len(number)-1], x.multiplier
+			break
+		}
+	}
+	u, e := strconv.ParseUint(strings.TrimSpace(number), 10, 64)
+	if e != nil {
+		if ne, ok := e.(*strconv.NumError); !ok || ne.Err != strconv.ErrRange {
+			return nil, fmt.Errorf("'%s' is not an unsigned 32 bit integer, optionally followed by KiB, MiB or GiB", s)
+		}
+	}
+	if e != nil || u > math.MaxUint32/multiplier {
+		return nil, fmt.Errorf("'%s' is larger than the maximum of %d (4GiB less one byte)", s, uint32(math.MaxUint32))
+	}
+	return uint32(u * multiplier), nil
+}
+
+// formatSize converts a uint32 to a string using the largest of the suffixes in sizeSuffixes that represents it exactly.
+func formatSize(u uint32) string {
+	for _, x := range sizeSuffixes {
+		if u != 0 && uint64(u)%x.multiplier == 0 {
+			return strconv.FormatUint(uint64(u)/x.multiplier, 10) + x.suffix
+		}
+	}
+	return strconv.FormatUint(uint64(u), 10)
+}
+
+// parsable returns true if parseValue can convert a string to the type that a Slot element points to.
+func parsable(slot interface{}) bool {
+	switch slot.(type) {
+	case *bool, *string, *int, *uint32, *float64, *[]string, *time.Duration:
+		return true
+	}
+	return false
+}
+
+// valueHandlers returns the functions in the Handler of a Var, those it does not have are nil.
+func valueHandlers(v []interface{}) (
+	parse func(string) (interface{}, error), format func(interface{}) string, validate func(interface{}) error) {
+	for _, x := range v {
+		if h, ok := x.(Handler); ok {
+			for _, y := range h {
+				switch f := y.(type) {
+				case func(string) (interface{}, error):
+					parse = f
+				case func(interface{}) string:
+					format = f
+				case func(interface{}) error:
+					validate = f
+				}
+			}
+		}
+	}
+	return
+}
+
+// allowedValues returns the values in the Allowed element of a Var or Arg converted to the type its Slot points to, or if it points to a slice, the type of its items, or nil if it has no Allowed element or Slot.
+func allowedValues(node []interface{}) (values []interface{}, e error) {
+	i, s := indexOf(node, Allowed{}), slotOf(Var(node))
+	if i < 0 || s == nil {
+		return nil, nil
+	}
+	t := itemType(s)
+	for j, x := range node[i].(Allowed) {
+		value, e := convertDefault(x, t)
+		if e != nil {
+			return nil, fmt.Errorf("value %v at index %d cannot be placed in a Slot of type %v", x, j, t)
+		}
+		values = append(values, value)
+	}
+	return
+}
+
+// allowedStrings returns the values in the Allowed element of a Var or Arg in the form they are given in the CLI args and configuration file, or nil if it has none.
+func allowedStrings(node []interface{}) (s []string) {
+	values, _ := allowedValues(node)
+	for _, x := range values {
+		s = append(s, formatItem(node, x))
+	}
+	return
+}
+
+// checkAllowed returns an error if a value for a Var or Arg is not one of the values in its Allowed element, or if its Slot points to a slice, if any of the items in the value are not. It returns nil if it has no Allowed element.
+func checkAllowed(node []interface{}, value interface{}) error {
+	allowed, e := allowedValues(node)
+	if e != nil || allowed == nil {
+		return e
+	}
+	items := []interface{}{value}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice && reflect.TypeOf(slotOf(Var(node))).Elem().Kind() == reflect.Slice {
+		items = nil
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, v.Index(i).Interface())
+		}
+	}
+	for _, x := range items {
+		found := false
+		for _, a := range allowed {
+			if reflect.DeepEqual(x, a) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("'%s' must be one of %s", formatItem(node, x), strings.Join(allowedStrings(node), ", "))
+		}
+	}
+	return nil
+}
+
+// sentinelPairs returns the values of the Sentinels of a Var, converted to the type its Slot points to, and their names, or nil if it has none or they cannot be converted.
+func sentinelPairs(v []interface{}) (values []interface{}, names []string) {
+	i, s := indexOf(v, Sentinels{}), slotOf(Var(v))
+	if i < 0 || s == nil {
+		return nil, nil
+	}
+	pairs := v[i].(Sentinels)
+	for j := 0; j+1 < len(pairs); j += 2 {
+		value, e := convertDefault(pairs[j], reflect.TypeOf(s).Elem())
+		name, ok := pairs[j+1].(string)
+		if e != nil || !ok {
+			return nil, nil
+		}
+		values, names = append(values, value), append(names, name)
+	}
+	return
+}
+
+// sentinelNamed returns the value of the Sentinel of a Var with the given name, ignoring case.
+func sentinelNamed(v []interface{}, name string) (interface{}, bool) {
+	values, names := sentinelPairs(v)
+	for i, n := range names {
+		if strings.EqualFold(n, name) {
+			return values[i], true
+		}
+	}
+	return nil, false
+}
+
+// sentinelName returns the name of the Sentinel of a Var with the given value.
+func sentinelName(v []interface{}, value interface{}) (string, bool) {
+	values, names := sentinelPairs(v)
+	for i, x := range values {
+		if reflect.DeepEqual(x, value) {
+			return names[i], true
+		}
+	}
+	return "", false
+}
+
+// checkBounds returns an error if a value for a Var is an integer less than its Min or more than its Max, unless it is one of its Sentinels.
+func checkBounds(v []interface{}, value interface{}) error {
+	n, ok := intValue(value)
+	if !ok {
+		return nil
+	}
+	if _, ok := sentinelName(v, value); ok {
+		return nil
+	}
+	if min, ok := bound(v, Min{}); ok && n < min {
+		return fmt.Errorf("%d is less than the minimum of %d", n, min)
+	}
+	if max, ok := bound(v, Max{}); ok && n > max {
+		return fmt.Errorf("%d is more than the maximum of %d", n, max)
+	}
+	return nil
+}
+
+// bound returns the value of the Min or Max of a Var, as given by the example, if it has one.
+func bound(v []interface{}, example interface{}) (int64, bool) {
+	i := indexOf(v, example)
+	if i < 0 {
+		return 0, false
+	}
+	x := reflect.ValueOf(v[i])
+	if x.Len() != 1 {
+		return 0, false
+	}
+	return intValue(x.Index(0).Interface())
+}
+
+// isInteger returns true for integer types other than time.Duration, whose values are not plain numbers.
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return t != reflect.TypeOf(time.Duration(0))
+	}
+	return false
+}
+
+// intValue returns the value of an integer of any size or signedness as an int64, and false if it is not an integer.
+func intValue(x interface{}) (int64, bool) {
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// itemType returns the type a Slot element points to, or if it points to a slice, the type of its items.
+func itemType(slot interface{}) reflect.Type {
+	t := reflect.TypeOf(slot).Elem()
+	if t.Kind() == reflect.Slice {
+		return t.Elem()
+	}
+	return t
+}
+
+// formatItem converts a value for a Var or Arg, or an item of it if its Slot points to a slice, into the string form it is given in.
+func formatItem(node []interface{}, value interface{}) string {
+	if t := reflect.TypeOf(slotOf(Var(node))).Elem(); t.Kind() == reflect.Slice {
+		return formatValue(value)
+	}
+	return formatVar(Var(node), value)
+}
+
+// slotValue returns the value currently held by the variable the first pointer in the Slot of a Var points to, or nil if it has no Slot.
+func slotValue(v Var) interface{} {
+	s := slotOf(v)
+	if s == nil {
+		return nil
+	}
+	return reflect.ValueOf(s).Elem().Interface()
+}
+
+// defaultValue returns the value of the Default of a Var converted to the type its Slot points to, in normal form if it is an Address or Port, or if it has none, the zero value of that type.
+func defaultValue(v Var) interface{} {
+	s := slotOf(v)
+	for _, x := range v {
+		if d, ok := x.(Default); ok && len(d) == 1 {
+			if s == nil {
+				return d[0]
+			}
+			if value, e := convertDefault(d[0], reflect.TypeOf(s).Elem()); e == nil {
+				if n, e := normalise(v, value); e == nil {
+					return n
+				}
+				return value
+			}
+			return d[0]
+		}
+	}
+	if s == nil {
+		return nil
+	}
+	return reflect.Zero(reflect.TypeOf(s).Elem()).Interface()
+}
+
+// convertDefault returns the value of a Default as the type a Slot points to. A value that is assignable is returned unchanged, nil stands for the zero value of types that can be nil, and a value of the same kind is converted, such as a string for a Slot of a named string type. Numbers are converted between numeric types only when the value is not changed by it, so Default{5} may be used for a uint32 or float64, but not Default{0.5} for an int, nor a plain number for a time.Duration, which would have no unit.
+func convertDefault(d interface{}, to reflect.Type) (interface{}, error) {
+	if d == nil {
+		switch to.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			return reflect.Zero(to).Interface(), nil
+		}
+		return nil, fmt.Errorf("Default is nil, which cannot be placed in a Slot of type %v", to)
+	}
+	v := reflect.ValueOf(d)
+	from := v.Type()
+	if from.AssignableTo(to) {
+		return d, nil
+	}
+	mismatch := fmt.Errorf("slot is not same type as default, %v cannot be placed in a Slot of type %v", from, to)
+	if !from.ConvertibleTo(to) {
+		return nil, mismatch
+	}
+	if isNumeric(from.Kind()) && isNumeric(to.Kind()) {
+		if to == reflect.TypeOf(time.Duration(0)) {
+			return nil, mismatch
+		}
+		out := v.Convert(to)
+		if out.Convert(from).Interface() != d {
+			return nil, fmt.Errorf("Default %v changes when converted to the type %v of the Slot", d, to)
+		}
+		return out.Interface(), nil
+	}
+	if from.Kind() != to.Kind() {
+		return nil, mismatch
+	}
+	return v.Convert(to).Interface(), nil
+}
+
+// isNumeric returns true for the kinds of the integer and floating point types.
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isDefault returns true if the value in the Slot of a Var is the same as its default value. Empty and nil slices are considered to be the same.
+func isDefault(v Var) bool {
+	value, def := slotValue(v), defaultValue(v)
+	a, b := reflect.ValueOf(value), reflect.ValueOf(def)
+	if a.Kind() == reflect.Slice && b.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(value, def)
+}
+
+// formatValue converts a value of one of the types a Slot can point to into the string form that parseValue reads.
+func formatValue(value interface{}) string {
+	switch x := value.(type) {
+	case bool:
+		return strconv.FormatBool(x)
+	case string:
+		return x
+	case int:
+		return strconv.Itoa(x)
+	case uint32:
+		return formatSize(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case []string:
+		return strings.Join(x, ",")
+	case time.Duration:
+		return x.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// formatVar converts a value for a Var into the string form that ParseVar reads, using the format function of its Handler if it has one, or if it is one of its Sentinels, its name.
+func formatVar(v Var, value interface{}) string {
+	if name, ok := sentinelName(v, value); ok {
+		return name
+	}
+	if _, format, _ := valueHandlers(v); format != nil {
+		return format(value)
+	}
+	return formatValue(value)
 }
@@ -0,0 +1,202 @@
+package tri
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadAllDefaults(t *testing.T) {
+	var logdir, ctldir string
+	var port int
+	tl := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"logdir", Brief{"brief"}, Default{"/log"}, Slot{&logdir}},
+		Var{"port", Brief{"brief"}, Slot{&port}},
+		Trigger{"wallet", Brief{"brief"}, DefaultOn{}, MakeTestHandler()},
+		Trigger{"reindex", Brief{"brief"}, MakeTestHandler()},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Var{"datadir", Brief{"brief"}, Default{"/ctl"}, Override{}, Slot{&ctldir}},
+				Trigger{"wallet", Brief{"brief"}, DefaultOn{}, Override{}, MakeTestHandler()},
+				MakeTestHandler(),
+			},
+		},
+	}
+
+	// every scope is walked and counted
+	report, e := LoadAllDefaults(&tl)
+	if e != nil {
+		t.Fatal("defaults were not loaded:", e)
+	}
+	if logdir != "/log" || ctldir != "/ctl" {
+		t.Error("defaults were not placed in Slots")
+	}
+	if report.VarsFound != 3 || report.DefaultsApplied != 2 || len(report.Errors) != 0 {
+		t.Error("report has wrong counts:", report)
+	}
+	if len(report.DefaultOn) != 2 || !sameNode(report.DefaultOn[1], tl[7].(Commands)[0][3].(Trigger)) {
+		t.Error("report did not find DefaultOn Triggers in every scope")
+	}
+
+	// problems are reported with their path and do not stop the other Vars from loading
+	logdir, ctldir = "", ""
+	var level []byte
+	tb := Tri{"appname",
+		Brief{"brief"},
+		Version{0, 1, 1},
+		Var{"nowhere", Brief{"brief"}, Default{"x"}},
+		Var{"logdir", Brief{"brief"}, Default{"/log"}, Slot{&logdir}},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Var{"level", Brief{"brief"}, Default{5}, Slot{&level}},
+				Var{"datadir", Brief{"brief"}, Default{"/ctl"}, Slot{&ctldir}},
+				MakeTestHandler(),
+			},
+		},
+	}
+	report, e = LoadAllDefaults(&tb)
+	if e == nil || len(report.Errors) != 2 || report.DefaultsApplied != 2 {
+		t.Fatal("invalid defaults were not reported:", report, e)
+	}
+	if logdir != "/log" || ctldir != "/ctl" {
+		t.Error("valid defaults were not loaded alongside invalid ones")
+	}
+	var ve *ValidationError
+	if !errors.As(e, &ve) || ve.Path != "appname/Var/nowhere/Slot" || ve.Index != -1 {
+		t.Error("missing Slot was not reported with its path:", ve)
+	}
+	if report.Errors[1].Path != "appname/Commands/ctl/Var/level/Default" || report.Errors[1].Index != 2 ||
+		!strings.Contains(e.Error(), "level") {
+		t.Error("unassignable Default was not reported with its path:", report.Errors[1])
+	}
+
+	// Address Defaults are loaded in normal form
+	var listen string
+	ta := Var{"listen", Brief{"brief"}, Address{11047}, Default{"LocalHost"}, Slot{&listen}}
+	if _, e := LoadDefaults(&ta); e != nil || listen != "localhost:11047" || !isDefault(ta) {
+		t.Error("Address Default was not loaded in normal form:", listen, e)
+	}
+
+	// a nil Default for an interface Slot places its zero value rather than panicking
+	var iface error = errors.New("not loaded")
+	parse := func(s string) (interface{}, error) { return errors.New(s), nil }
+	ti := Var{"iface", Brief{"brief"}, Default{nil}, Handler{parse}, Slot{&iface}}
+	if e := ti.Validate(); e != nil {
+		t.Fatal("test declaration is invalid:", e)
+	}
+	if found, e := LoadDefaults(&ti); !found || e != nil || iface != nil {
+		t.Error("nil Default was not loaded into an interface Slot:", iface, e)
+	}
+
+	// a nil pointer in a Slot is an error rather than a panic
+	var p *string
+	tn := Var{"nil", Brief{"brief"}, Default{"x"}, Slot{p}}
+	if found, e := LoadDefaults(&tn); found || e == nil {
+		t.Error("nil pointer in Slot was not reported")
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	var address, port string
+	var peers []string
+	va := Var{"address", Brief{"brief"}, Address{11048}, Slot{&address}}
+	vn := Var{"address", Brief{"brief"}, Address{}, Slot{&address}}
+	for _, x := range []struct {
+		v        Var
+		in, want string
+	}{
+		{va, "127.0.0.1:8333", "127.0.0.1:8333"},
+		{va, "127.0.0.1", "127.0.0.1:11048"},
+		{va, "Seed.Example.COM", "seed.example.com:11048"},
+		{va, "[::1]:80", "[::1]:80"},
+		{va, "[::1]", "[::1]:11048"},
+		{va, "::1", "[::1]:11048"},
+		{va, ":11047", ":11047"},
+		{va, "host:0080", "host:80"},
+		{vn, "host:80", "host:80"},
+		{va, "", ""},
+	} {
+		if e := ParseVar(&x.v, x.in); e != nil || address != x.want {
+			t.Errorf("address %q: expected %q, got %q %v", x.in, x.want, address, e)
+		}
+	}
+	for _, x := range []struct {
+		v  Var
+		in string
+	}{
+		{vn, "host"},
+		{va, "host:"},
+		{va, "host:65536"},
+		{va, "host:port"},
+		{va, "a:b:c"},
+		{va, "[host]"},
+		{va, "[host]:80"},
+		{va, "ho st"},
+		{va, "[::1"},
+		{va, "::g"},
+	} {
+		if e := ParseVar(&x.v, x.in); e == nil {
+			t.Errorf("invalid address %q was accepted as %q", x.in, address)
+		}
+	}
+
+	// lists are normalised and repeated addresses removed, also when they are added to
+	vl := Var{"peers", Brief{"brief"}, Address{11047}, Slot{&peers}}
+	if e := ParseVar(&vl, "a,b:11047,A:11047,[::1],::1"); e != nil || strings.Join(peers, " ") != "a:11047 b:11047 [::1]:11047" {
+		t.Error("address list was not normalised:", peers, e)
+	}
+	if e := AppendVar(&vl, "c,b"); e != nil || strings.Join(peers, " ") != "a:11047 b:11047 [::1]:11047 c:11047" {
+		t.Error("repeated address was added to list:", peers, e)
+	}
+	if e := ParseVar(&vl, "a,,b"); e != nil || strings.Join(peers, " ") != "a:11047 b:11047" {
+		t.Error("empty item in address list was not dropped:", peers, e)
+	}
+
+	// ports are checked and written without leading zeros
+	vp := Var{"port", Brief{"brief"}, Port{}, Slot{&port}}
+	if e := ParseVar(&vp, "011048"); e != nil || port != "11048" {
+		t.Error("port was not normalised:", port, e)
+	}
+	for _, x := range []string{"0", "65536", "-1", "+80", "port"} {
+		if e := ParseVar(&vp, x); e == nil {
+			t.Errorf("invalid port %q was accepted", x)
+		}
+	}
+}
+
+func TestURLs(t *testing.T) {
+	var endpoint string
+	vu := Var{"rpc", Brief{"brief"}, URL{"http", "https"}, Host{}, Slot{&endpoint}}
+	for _, x := range []string{"http://127.0.0.1:11048", "HTTPS://user@[::1]/path?q=1", ""} {
+		if e := ParseVar(&vu, x); e != nil || endpoint != x {
+			t.Errorf("valid URL %q was not accepted: %q %v", x, endpoint, e)
+		}
+	}
+	for _, x := range []struct{ in, err string }{
+		{"127.0.0.1:11048", "not a valid URL"},
+		{"localhost:11048", "must be one of http, https"},
+		{"/path", "no scheme"},
+		{"ftp://host", "must be one of http, https"},
+		{"http:///path", "no host"},
+		{"http://host:0", "has an invalid port: port 0 is not between 1 and 65535"},
+		{"http://host:99999", "invalid port"},
+		{"http://host:port", "not a valid URL"},
+		{"http://[::1", "not a valid URL"},
+	} {
+		if e := ParseVar(&vu, x.in); e == nil || !strings.Contains(e.Error(), x.err) {
+			t.Errorf("invalid URL %q: expected error containing %q, got %v", x.in, x.err, e)
+		}
+	}
+
+	// without Host and schemes any URL with a scheme is accepted, each in a list is checked
+	var urls []string
+	vl := Var{"urls", Brief{"brief"}, URL{}, Slot{&urls}}
+	if e := ParseVar(&vl, "file:///a,mailto:me@host"); e != nil || len(urls) != 2 {
+		t.Error("valid URLs were not accepted:", urls, e)
+	}
+	if e := ParseVar(&vl, "file:///a,b"); e == nil {
+		t.Error("invalid URL in a list was accepted")
+	}
+}
@@ -0,0 +1,234 @@
+package tri
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Run is the entry point for an application declared with a Tri, it is passed the CLI args (without the executable name, ie. os.Args[1:]) and returns the exit code for the application.
+//
+// The Tri is validated, printing every problem found if it is not valid, the defaults are loaded, the data directory is created and the configuration file inside it is read, unless the built-in init Trigger is named, which deletes it, and then the values from the CLI args are placed in their Slots. If no Command is named in the CLI args, the DefaultCommand is used, or if there is none, the built-in help Command.
+//
+// Triggers at the root, in the selected Command and in the Commands it is nested in run if they were named in the CLI args or in the configuration, or, if they are DefaultOn, if they were not. The built-in Triggers run first, followed by the others in the order they were declared. A Trigger that returns nonzero stops execution with its return value, as does a Trigger that Terminates, once it completes.
+//
+// Every Required Var at the root and in the selected Commands must then have been given a value, if any have not, they are all listed, see CheckRequired, and the exit code is 1. The built-in Commands run without this check. Then the handler of the Command runs, or if it has none, because it only holds other Commands, its help is shown, followed by the RunAfter Triggers. The exit code is that returned by the Command handler, or if it is zero, the first nonzero value returned by a RunAfter Trigger.
+func (r *Tri) Run(args []string) int {
+	if e := r.ValidateAll(); e != nil {
+		fmt.Fprintf(os.Stderr, "invalid declaration:\n%v\n", e)
+		return 1
+	}
+	if _, e := LoadAllDefaults(r); e != nil {
+		fmt.Fprintf(os.Stderr, "unable to load defaults:\n%v\n", e)
+		return 1
+	}
+	inv, e := r.scan(args)
+	if e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		return 1
+	}
+	// the data directory can only be set from the CLI args and must be known before the configuration can be read, it is removed from the values so that apply does not replace the expanded path MakeDataDir places in its Slot
+	dd := r.dataDirVar()
+	values := inv.values[:0]
+	for _, x := range inv.values {
+		if !sameNode(x.v, dd) {
+			values = append(values, x)
+			continue
+		}
+		if e = ParseVar(&x.v, x.value); e != nil {
+			fmt.Fprintf(os.Stderr, "argument %d: invalid value for Var %s: %v\n", x.index, x.path, e)
+			return 1
+		}
+		inv.assigned = append(inv.assigned, x.v)
+	}
+	inv.values = values
+	if _, e = r.MakeDataDir(); e != nil {
+		fmt.Fprintln(os.Stderr, "unable to create data directory:", e)
+		return 1
+	}
+	// the init Trigger deletes the configuration file, so it is not read if init is named, as it may be one that cannot be read
+	initNamed := false
+	for _, t := range inv.Triggers {
+		if isBuiltin(t) && nameOf(t) == "init" {
+			initNamed = true
+		}
+	}
+	if !initNamed {
+		triggers, assigned, e := r.loadConfig(r.ConfigFile())
+		if e != nil {
+			fmt.Fprintln(os.Stderr, e)
+			return 1
+		}
+		inv.Triggers = append(triggers, inv.Triggers...)
+		inv.assigned = append(inv.assigned, assigned...)
+	}
+	if e = inv.apply(); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		return 1
+	}
+	if inv.Command == nil {
+		inv.Command = r.commandNamed("help")
+	}
+	if len(inv.Path) < 1 && inv.Command != nil {
+		inv.Path = []Command{inv.Command}
+	}
+	r.record(inv)
+
+	before, after := r.triggers(inv)
+	for _, t := range before {
+		code := handlerOf(t)(r)
+		if code != 0 {
+			return code
+		}
+		for _, x := range t {
+			if _, ok := x.(Terminates); ok {
+				return 0
+			}
+		}
+	}
+	if inv.Command == nil || !isBuiltin(inv.Command) {
+		if e = r.CheckRequired(); e != nil {
+			fmt.Fprintln(os.Stderr, e)
+			return 1
+		}
+	}
+	var code int
+	if inv.Command != nil && !hasHandler(inv.Command) {
+		// a Command that only holds other Commands shows its help when none of them is named
+		code = showHelp(r, commandPath(inv.Path))
+	} else if inv.Command != nil {
+		code = handlerOf(inv.Command)(r)
+	}
+	for _, t := range after {
+		if c := handlerOf(t)(r); code == 0 {
+			code = c
+		}
+	}
+	return code
+}
+
+// MissingError is the error returned by CheckRequired, listing every Required Var that has not been given a value, and where each of them can be set.
+type MissingError struct {
+	// Paths are the names of the missing Vars prefixed by the names of the Commands they belong to, if any, as in commandname/varname
+	Paths []string
+	lines []string
+}
+
+// Error lists the missing Vars, one on each line, with the CLI flag and the place in the configuration file that sets it.
+func (m *MissingError) Error() string {
+	return "missing required values:\n" + strings.Join(m.lines, "\n")
+}
+
+// CheckRequired returns a *MissingError listing every Required Var, at the root of the Tri and in the Commands of the last Invocation, that was not given a value in the CLI args of the Invocation, or by Run, in the configuration file, or nil if there are none. A value that is the zero value of the type of the Var, such as false, 0 or an empty string, counts as given. A Var at the root that is overridden by one in the selected Commands is not checked, as it cannot be set.
+func (r *Tri) CheckRequired() error {
+	inv := r.Invocation()
+	if inv == nil {
+		inv = new(Invocation)
+	}
+	commands := inv.Path
+	m := new(MissingError)
+	check := func(container []interface{}, scope []Command) {
+		for _, x := range container {
+			v, ok := x.(Var)
+			if !ok || !hasFlag(v, Required{}) || inv.given(v) {
+				continue
+			}
+			if item, _ := r.lookup(commands, nameOf(v)); !sameVar(item, v) {
+				continue
+			}
+			name := strings.ToLower(nameOf(v))
+			flag := "--" + name
+			if s, ok := shortOf(v); ok {
+				flag += " (-" + string(s) + ")"
+			}
+			path, where := name, "at the start of a line"
+			if len(scope) > 0 {
+				command := strings.ToLower(commandPath(scope))
+				path = commandPath(scope) + "/" + nameOf(v)
+				flag += " after " + strings.Replace(command, "/", " ", -1)
+				where = "after a tab under " + command
+			}
+			m.Paths = append(m.Paths, path)
+			m.lines = append(m.lines, fmt.Sprintf("    %s: set it with %s, or %s %s in %s",
+				path, flag, name, where, r.ConfigFile()))
+		}
+	}
+	check(*r, nil)
+	for i, c := range commands {
+		check(c, commands[:i+1])
+	}
+	if len(m.Paths) > 0 {
+		return m
+	}
+	return nil
+}
+
+// triggers returns the Triggers at the root of the Tri and in the selected Command, and the Commands it is nested in, that are to run for an Invocation, those to run before the Command handler, and those to run after it. Built-in Triggers are placed first.
+func (r *Tri) triggers(inv *Invocation) (before, after []Trigger) {
+	var all, declared []Trigger
+	containers := [][]interface{}{*r}
+	for _, c := range inv.Path {
+		containers = append(containers, c)
+	}
+	for _, c := range containers {
+		for _, x := range c {
+			if t, ok := x.(Trigger); ok {
+				if isBuiltin(t) {
+					all = append(all, t)
+				} else {
+					declared = append(declared, t)
+				}
+			}
+		}
+	}
+	for _, t := range append(all, declared...) {
+		named := false
+		for _, x := range inv.Triggers {
+			if sameNode(x, t) {
+				named = true
+				break
+			}
+		}
+		if named == isDefaultOn(t) {
+			continue
+		}
+		runAfter := false
+		for _, x := range t {
+			if _, ok := x.(RunAfter); ok {
+				runAfter = true
+			}
+		}
+		if runAfter {
+			after = append(after, t)
+		} else {
+			before = append(before, t)
+		}
+	}
+	return
+}
+
+// sameVar returns true if an item found by lookup is the given Var.
+func sameVar(item interface{}, v Var) bool {
+	y, ok := item.(Var)
+	return ok && sameNode(y, v)
+}
+
+// hasHandler returns true if a Command or Trigger contains a handler function.
+func hasHandler(node []interface{}) bool {
+	for _, x := range node {
+		if _, ok := x.(func(*Tri) int); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerOf returns the handler function of a Command or Trigger.
+func handlerOf(node []interface{}) func(*Tri) int {
+	for _, x := range node {
+		if h, ok := x.(func(*Tri) int); ok {
+			return h
+		}
+	}
+	return func(*Tri) int { return 0 }
+}
@@ -0,0 +1,239 @@
+package tri
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	home, e := ioutil.TempDir("", "tri")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	var ran []string
+	record := func(name string, code int) func(*Tri) int {
+		return func(*Tri) int {
+			ran = append(ran, name)
+			return code
+		}
+	}
+	var port int
+	var rpcuser string
+	var peers []string
+	tr := Tri{"appname", Brief{"brief"}, Version{0, 1, 1},
+		DefaultCommand{"node"},
+		Var{"port", Brief{"brief"}, Default{1}, Slot{&port}},
+		Var{"peers", Brief{"brief"}, Default{[]string{"seed"}}, Slot{&peers}},
+		Trigger{"wallet", Brief{"brief"}, DefaultOn{}, record("wallet", 0)},
+		Trigger{"backup", Brief{"brief"}, RunAfter{}, record("backup", 3)},
+		Trigger{"fail", Brief{"brief"}, record("fail", 2)},
+		Trigger{"stop", Brief{"brief"}, Terminates{}, record("stop", 0)},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Trigger{"ctltrig", Brief{"brief"}, record("ctltrig", 0)},
+				record("ctl", 0),
+			},
+			{"node", Brief{"brief"},
+				Var{"rpcuser", Brief{"brief"}, Slot{&rpcuser}},
+				record("node", 0),
+			},
+			{"bad", Brief{"brief"}, record("bad", 5)},
+		},
+	}
+	for i, x := range []struct {
+		args []string
+		ran  string
+		code int
+	}{
+		// DefaultCommand when none is named, DefaultOn triggers run unless named
+		{nil, "wallet node", 0},
+		{[]string{"--wallet", "ctl"}, "ctl", 0},
+		// Command triggers, root triggers named after the Command
+		{[]string{"ctl", "--ctltrig", "--backup"}, "wallet ctltrig ctl backup", 3},
+		// the Command's exit code takes precedence over RunAfter triggers
+		{[]string{"bad", "--backup"}, "wallet bad backup", 5},
+		// nonzero trigger stops execution
+		{[]string{"--fail", "--backup"}, "wallet fail", 2},
+		// terminating trigger stops execution after completing
+		{[]string{"--stop", "--backup"}, "wallet stop", 0},
+		// errors in the CLI args
+		{[]string{"--nothere"}, "", 1},
+		{[]string{"--port=x"}, "", 1},
+	} {
+		ran = nil
+		code := tr.Run(x.args)
+		if code != x.code || strings.Join(ran, " ") != x.ran {
+			t.Errorf("test %d: expected '%s' and exit code %d, got '%s' and %d",
+				i, x.ran, x.code, strings.Join(ran, " "), code)
+		}
+	}
+
+	// the Vars of the DefaultCommand can be set when no Command is named
+	ran = nil
+	if tr.Run([]string{"--rpcuser", "u"}) != 0 || rpcuser != "u" || strings.Join(ran, " ") != "wallet node" {
+		t.Error("Var of the DefaultCommand not set", rpcuser, ran)
+	}
+	rpcuser = ""
+
+	// the configuration is loaded over the defaults and under the CLI args
+	conf := filepath.Join(home, ".appname", ConfigFileName)
+	if e = ioutil.WriteFile(conf, []byte("port 2\nwallet\n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	ran = nil
+	if tr.Run([]string{"ctl"}) != 0 || port != 2 || strings.Join(ran, " ") != "ctl" {
+		t.Error("configuration not loaded", port, ran)
+	}
+	if tr.Run([]string{"--port", "3", "ctl"}) != 0 || port != 3 {
+		t.Error("CLI args did not override configuration", port)
+	}
+	// the CLI args add to a list from the configuration, even one the same as the default, and otherwise replace the default
+	if tr.Run([]string{"--peers=a", "ctl"}) != 0 || strings.Join(peers, " ") != "a" {
+		t.Error("CLI args did not replace the default list", peers)
+	}
+	if e = ioutil.WriteFile(conf, []byte("port 2\nwallet\npeers seed\n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	if tr.Run([]string{"--peers=a", "ctl"}) != 0 || strings.Join(peers, " ") != "seed a" {
+		t.Error("CLI args did not add to the list from the configuration", peers)
+	}
+
+	// datadir from the CLI args is where the configuration is read from
+	if tr.Run([]string{"-D", filepath.Join(home, "other"), "--save", "--port=4"}) != 0 {
+		t.Fatal("run failed")
+	}
+	saved, e := ioutil.ReadFile(filepath.Join(home, "other", ConfigFileName))
+	if e != nil || string(saved) != "port 4\nctl\nnode\nbad\n" {
+		t.Errorf("save trigger did not write to the data directory: %q %v", saved, e)
+	}
+	// the expanded path is left in the Slot of the datadir Var
+	if tr.Run([]string{"--datadir", "~/other"}) != 0 {
+		t.Fatal("run failed")
+	}
+	if d, _ := slotValue(tr.dataDirVar()).(string); d != filepath.Join(home, "other") {
+		t.Error("datadir Slot does not hold the expanded path:", d)
+	}
+	if inv := tr.Invocation(); inv == nil || nameOf(inv.Command) != "node" {
+		t.Error("Invocation not recorded in the Tri")
+	}
+
+	// init deletes a configuration file that cannot be read
+	if e = ioutil.WriteFile(conf, []byte("bogus 1\n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	if tr.Run([]string{"ctl"}) != 1 {
+		t.Error("invalid configuration was not reported")
+	}
+	ran = nil
+	if tr.Run([]string{"--init"}) != 0 || len(ran) != 0 {
+		t.Error("init did not run with an invalid configuration", ran)
+	}
+	if _, e = os.Stat(conf); !os.IsNotExist(e) {
+		t.Error("init did not delete an invalid configuration:", e)
+	}
+
+	// Triggers of every enclosing Command run, a Command that only holds others shows its help
+	tn := Tri{"nested", Brief{"brief"}, Version{0, 1, 1},
+		Trigger{"wallet", Brief{"brief"}, DefaultOn{}, record("wallet", 0)},
+		Commands{
+			{"chain", Brief{"brief"},
+				Trigger{"sync", Brief{"brief"}, record("sync", 0)},
+				Commands{
+					{"block", Brief{"brief"},
+						Trigger{"verify", Brief{"brief"}, record("verify", 0)},
+						record("block", 4),
+					},
+				},
+			},
+		},
+	}
+	for i, x := range []struct {
+		args []string
+		ran  string
+		code int
+	}{
+		{[]string{"chain", "--sync", "block", "--verify"}, "wallet sync verify block", 4},
+		{[]string{"chain"}, "wallet", 0},
+		{[]string{"chain", "nothere"}, "", 1},
+	} {
+		ran = nil
+		code := tn.Run(x.args)
+		if code != x.code || strings.Join(ran, " ") != x.ran {
+			t.Errorf("nested test %d: expected '%s' and exit code %d, got '%s' and %d",
+				i, x.ran, x.code, strings.Join(ran, " "), code)
+		}
+	}
+	if inv := tn.Invocation(); inv == nil || commandPath(inv.Path) != "chain" {
+		t.Error("Invocation of nested Command not recorded in the Tri")
+	}
+
+	// every missing Required Var in the selected Commands is reported together, before the Command handler runs
+	var user, pass, peer string
+	tq := Tri{"required", Brief{"brief"}, Version{0, 1, 1},
+		Var{"rpcuser", Short{'u'}, Brief{"brief"}, Required{}, Slot{&user}},
+		Commands{
+			{"ctl", Brief{"brief"},
+				Var{"rpcpass", Brief{"brief"}, Required{}, Slot{&pass}},
+				record("ctl", 0),
+			},
+			{"node", Brief{"brief"},
+				Var{"peer", Brief{"brief"}, Required{}, Slot{&peer}},
+				record("node", 0),
+			},
+		},
+	}
+	ran = nil
+	if tq.Run([]string{"ctl"}) != 1 || len(ran) != 0 {
+		t.Error("Command ran without its Required Vars")
+	}
+	e = tq.CheckRequired()
+	m, ok := e.(*MissingError)
+	if !ok || strings.Join(m.Paths, " ") != "rpcuser ctl/rpcpass" {
+		t.Fatal("missing Required Vars were not all reported:", e)
+	}
+	conf = filepath.Join(home, ".required", ConfigFileName)
+	for _, s := range []string{"--rpcuser (-u)", "--rpcpass after ctl", "under ctl in " + conf} {
+		if !strings.Contains(e.Error(), s) {
+			t.Errorf("missing Var error does not show '%s':\n%v", s, e)
+		}
+	}
+	if e = ioutil.WriteFile(conf, []byte("rpcuser me\nctl\n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	if tq.Run([]string{"ctl", "--rpcpass", "secret"}) != 0 || strings.Join(ran, " ") != "ctl" {
+		t.Error("Required Vars given in the configuration and CLI args were not accepted", ran)
+	}
+	if tq.Run([]string{"help"}) != 0 {
+		t.Error("built-in help Command checked Required Vars")
+	}
+
+	// an explicit zero value counts as given, a value left in a Slot by an earlier run does not
+	var count int
+	var flag bool
+	var name string
+	tz := Tri{"zero", Brief{"brief"}, Version{0, 1, 1},
+		Var{"count", Brief{"brief"}, Required{}, Slot{&count}},
+		Var{"flag", Brief{"brief"}, Required{}, Slot{&flag}},
+		Var{"name", Brief{"brief"}, Required{}, Slot{&name}},
+		Commands{{"run", Brief{"brief"}, record("run", 0)}},
+	}
+	if tz.Run([]string{"run", "--count=0", "--flag=false", "--name="}) != 0 {
+		t.Error("explicit zero values of Required Vars were not accepted:", tz.CheckRequired())
+	}
+	if tz.Run([]string{"run", "--count=0"}) != 1 {
+		t.Error("Required Vars set in an earlier run were accepted")
+	}
+	if e = ioutil.WriteFile(filepath.Join(home, ".zero", ConfigFileName), []byte("flag false\nname \n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	if tz.Run([]string{"run", "--count=0"}) != 0 {
+		t.Error("zero values of Required Vars in the configuration were not accepted:", tz.CheckRequired())
+	}
+}
@@ -37,10 +37,29 @@ type Branch interface {
 
 // TODO: write the english version of what structure each of these has
 
+// Address is a flag for a Var with a *string or *[]string Slot holding network addresses in the form host:port, where the host is a name, an IPv4 address, or an IPv6 address in square brackets. It may contain one integer, the port that is added to an address that has none, without which every address must have a port. Addresses are checked and written in the same form, and repeated addresses are removed from lists.
+type Address Tri
+
+// Allowed is the set of values a Var or Arg accepts, such as the names of networks, which must be of the type its Slot points to, or for a *[]string Slot, strings that each item in the list must be one of. Any other value in the CLI args or configuration file is an error, the Default must be one of them, and they are listed in the help and offered by Complete.
+type Allowed Tri
+
+// Arg is a positional operand of a Command, found in the CLI args after the Command is named. Like a Var it has a name, Brief and Slot the value is placed into, and optionally Help, Usage, Default, Handler, Precision and Allowed. It is required unless it contains Optional, and if it contains Variadic it takes every remaining operand into a *[]string Slot. The Args of a Command are matched with the operands in the order they are declared, so required Args come before Optional ones, and a Variadic Arg is last.
+/*
+	{"name",
+		Brief{"brief"},
+		Help{"help"},
+		Optional{},
+		Variadic{},
+		Default{"value"},
+		Slot{&variable},
+	}
+*/
+type Arg Tri
+
 // Brief is a short description up to 80 characters long containing one string with no control characters, that is intended to describe the item it is embedded in.
 type Brief Tri
 
-// Command is the specification for an individual subcommand. Shown below is the full set of allowable items, the metadata items may only appear once, there must be a Brief, the name at the start, and a Handler function.
+// Command is the specification for an individual subcommand. Shown below is the full set of allowable items, the metadata items may only appear once, there must be a Brief, the name at the start, and a Handler function, which may only be left out if the Command contains Commands of its own, nested to any depth, such as `pod wallet account create`.
 /*
 	{"name",
 		Short{"c"}, // single character shortcut for full length name
@@ -55,6 +74,10 @@ type Brief Tri
 		},
 		Trigger{...
 		},
+		Arg{...
+		},
+		Commands{...
+		},
 		func(Tri) int {
 			...
 			return 0
@@ -81,12 +104,42 @@ type Examples Tri
 // Group is a single string tag with the same format as name fields that functions as a tag to gather related items in the help output.
 type Group Tri
 
-// Help is a free-form text that is interpreted as markdown syntax and may optionally be formatted using ANSI codes by a preprocessor to represent the structured text that a markdown parser will produce, by default all markdown annotations will be removed.
+// Handler contains functions that convert the value of a Var from and to the strings found in the CLI args and configuration file, and check that a value is acceptable. It may contain one each of a func(string) (interface{}, error) that parses a string, a func(interface{}) string that formats a value, and a func(interface{}) error that validates a value, in any order. This allows Slots to point to types other than the built-in set, which must then have a parse function, and restricts values further than their type does.
+type Handler Tri
+
+// Help is a free-form text that is interpreted as markdown syntax and may optionally be formatted using ANSI codes by a preprocessor to represent the structured text that a markdown parser will produce, by default all markdown annotations will be removed. See RenderHelp and HelpStyle.
 type Help Tri
 
+// Host is a flag for a URL Var indicating that its URLs must have a host, as those of network endpoints such as RPC servers and proxies do. A port is optional, but if it is given it must be between 1 and 65535.
+type Host Tri
+
+// Max is the largest value a Var with an integer Slot, such as an int or uint32, accepts, other than its Sentinels.
+type Max Tri
+
+// Min is the smallest value a Var with an integer Slot, such as an int or uint32, accepts, other than its Sentinels. Most uint32 Vars should have Min{1}, as zero is not a useful value for them.
+type Min Tri
+
+// Optional is a flag for an Arg indicating that it may be left out of the CLI args, in which case its Slot keeps its Default.
+type Optional Tri
+
+// Override is a flag for a Var or Trigger in a Command indicating that it intentionally has the same name or Short as an item at the root of the Tri, which it takes the place of when the Command is selected.
+type Override Tri
+
+// Port is a flag for a Var with a *string or *int Slot holding a network port number, which must be between 1 and 65535.
+type Port Tri
+
+// Precision is the number of decimal places kept when a string is parsed into a Var with a float64 Slot, any beyond it are truncated. Without it, DefaultPrecision is used, as float64 Vars are usually currency amounts, and Precision{-1} keeps every decimal place.
+type Precision Tri
+
+// Required is a flag for a Var indicating that it must be given a value, in the configuration file or the CLI args, as it has no Default that would do, see CheckRequired.
+type Required Tri
+
 // RunAfter is a flag indicating that a Trigger element of a Command should be run during shutdown instead of before startup.
 type RunAfter Tri
 
+// Sentinels is a list of pairs of a value and a name, for values of a Var with an integer Slot that have a special meaning, such as Sentinels{-1, "all"} for a number of threads. The names can be given in place of the values in the CLI args and the configuration file, the values are shown by their names in the help, the configuration file and the defaults, and they are accepted even when outside the Min and Max.
+type Sentinels Tri
+
 // Short is a single character symbol that can be used instead of the name at the top of the Tri-derived type in invocation.
 type Short Tri
 
@@ -124,9 +177,15 @@ type Tri []interface{}
 // Trigger is for initiating the execution of one-shot functions that often terminate execution, or rewrite, sort, re-index, and this kind of thing.
 type Trigger Tri
 
+// URL is a flag for a Var with a *string or *[]string Slot holding URLs, which must have a scheme. It may contain the schemes that are allowed, such as URL{"http", "https"}, otherwise any scheme is allowed.
+type URL Tri
+
 // Usage is is an example showing the invocation of a Tri CLI flag.
 type Usage Tri
 
+// Variadic is a flag for the last Arg of a Command indicating that it takes every remaining operand in the CLI args, into a *[]string Slot.
+type Variadic Tri
+
 // Var is defines a configuration variable and the means to populate this variable in an optionally separate configuration structure.
 type Var Tri
 
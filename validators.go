@@ -1,13 +1,198 @@
 package tri
 
 import (
-	"time"
 	"reflect"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"unicode"
 )
 
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Address may be empty, or contain one integer, the default port, which must be between 1 and 65535.
+func (r *Address) Validate() error {
+
+	R := *r
+	if len(R) > 1 {
+		return errors.New("Address may contain only one element, the default port")
+	}
+	if len(R) == 1 {
+		p, ok := R[0].(int)
+		if !ok {
+			return errors.New("Address element must be an integer port number")
+		}
+		if p < 1 || p > maxPort {
+			return fmt.Errorf("Address default port must be between 1 and %d, found %d", maxPort, p)
+		}
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Allowed must contain at least one value, none of which may be nil, and no value may appear more than once. That the values are of the type of the Slot is checked in the Var and Arg validators.
+func (r *Allowed) Validate() error {
+
+	R := *r
+	if len(R) < 1 {
+		return errors.New("Allowed must contain at least one value")
+	}
+	for i, x := range R {
+		if x == nil {
+			return fmt.Errorf("Allowed contains nil at index %d", i)
+		}
+		for j := 0; j < i; j++ {
+			if reflect.DeepEqual(R[j], x) {
+				return fmt.Errorf("Allowed contains %v more than once, at index %d and %d", x, j, i)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Arg must contain (one) name, Brief and Slot, and nothing other than these and Help, Usage, Default, Handler, Precision, Allowed, Optional and Variadic. A Variadic Arg must have a *[]string Slot. Its place among the other Args of its Command is checked in the Command validator.
+func (r *Arg) Validate() error {
+	p := &problems{failFast: true}
+	r.validate(pathOf("Arg", *r), p)
+	return p.err()
+}
+
+// validate checks an Arg found at the given path, adding the problems it finds to p, and returns true if there were none.
+func (r *Arg) validate(path string, p *problems) bool {
+	R := *r
+	valid := true
+	var name string
+	if len(R) > 0 {
+		name, _ = R[0].(string)
+	}
+	// fail records a problem with the element at index, of the given kind, or with the node as a whole if the index is -1
+	fail := func(index int, element string, e error) bool {
+		valid = false
+		return p.add(newValidationError(path, "Arg", name, index, element, e))
+	}
+	if len(R) < 3 {
+		fail(-1, "", errors.New("Arg must contain a name, Brief and Slot at minimum"))
+		return false
+	}
+	name, ok := R[0].(string)
+	if !ok {
+		fail(0, "", errors.New("first element of Arg must be the name"))
+		return false
+	} else if e := ValidName(name); e != nil {
+		if fail(0, "", fmt.Errorf("Invalid Name in Arg at index 0: %v", e)) {
+			return false
+		}
+	}
+	// validSet is an array that represent the presence of the mandatory parts.
+	var validSet [2]bool
+	brief, slot := 0, 1
+	// singleSet is an array representing the optional elements that may not be more than one inside an Arg
+	var singleSet [8]bool
+	usage, help, def, handler, precision, optional, variadic, allowed := 0, 1, 2, 3, 4, 5, 6, 7
+	// single checks that an element only appears once and is itself valid
+	single := func(which int, kind string, i int, e error) bool {
+		if singleSet[which] {
+			if fail(i, kind, fmt.Errorf("Arg may only contain one %s, extra found at index %d", kind, i)) {
+				return true
+			}
+		}
+		singleSet[which] = true
+		return e != nil && fail(i, kind, e)
+	}
+	// elementsValid is cleared when an element is invalid, as the checks between elements depend on them
+	elementsValid := true
+	for i, x := range R {
+		if i == 0 {
+			continue
+		}
+
+		var stop bool
+		before := valid
+		switch y := x.(type) {
+
+		case Brief:
+			if validSet[brief] {
+				if fail(i, "Brief", fmt.Errorf("Arg may (only) contain one Brief, second found at index %d", i)) {
+					return false
+				}
+			}
+			validSet[brief] = true
+			if e := y.Validate(); e != nil {
+				stop = fail(i, "Brief", e)
+			}
+
+		case Slot:
+			if validSet[slot] {
+				if fail(i, "Slot", fmt.Errorf("Arg may only contain one Slot, extra found at index %d", i)) {
+					return false
+				}
+			}
+			validSet[slot] = true
+			if e := y.Validate(); e != nil {
+				stop = fail(i, "Slot", e)
+			}
+
+		case Usage:
+			stop = single(usage, "Usage", i, y.Validate())
+
+		case Help:
+			stop = single(help, "Help", i, y.Validate())
+
+		case Default:
+			stop = single(def, "Default", i, y.Validate())
+
+		case Handler:
+			stop = single(handler, "Handler", i, y.Validate())
+
+		case Precision:
+			stop = single(precision, "Precision", i, y.Validate())
+
+		case Optional:
+			stop = single(optional, "Optional", i, y.Validate())
+
+		case Variadic:
+			stop = single(variadic, "Variadic", i, y.Validate())
+
+		case Allowed:
+			stop = single(allowed, "Allowed", i, y.Validate())
+
+		default:
+			stop = fail(i, "", fmt.Errorf(
+				"found invalid item type at element %d in an Arg", i))
+		}
+		if stop {
+			return false
+		}
+		if before && !valid {
+			elementsValid = false
+		}
+	}
+	if !(validSet[brief] && validSet[slot]) {
+		// the checks that follow need the Slot
+		fail(-1, "", errors.New("Arg must contain one each of Brief and Slot"))
+		return false
+	}
+	if !elementsValid {
+		return false
+	}
+	s := slotOf(Var(R))
+	if d := indexOf(R, Default{}); d >= 0 {
+		if _, e := convertDefault(R[d].(Default)[0], reflect.TypeOf(s).Elem()); e != nil {
+			if fail(d, "Default", e) {
+				return false
+			}
+		}
+	}
+	if _, ok := s.(*[]string); singleSet[variadic] && !ok {
+		if fail(indexOf(R, Variadic{}), "Variadic", fmt.Errorf("Variadic Arg %s must have a *[]string Slot", name)) {
+			return false
+		}
+	}
+	checkValue(R, "Arg", fail)
+	return valid
+}
+
 // Validate checks to ensure the contents of this node type satisfy constraints.
 // Brief only contains one thing, so we make sure it has it - one string. This string may not contain any type of control characters, and is limited to 80 characters in length.
 func (r *Brief) Validate() error {
@@ -32,118 +217,239 @@ func (r *Brief) Validate() error {
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// This validator only has to check the elements of the slice are zero or more Command items, and a valid name at index 0.
+// This validator only has to check the elements of the slice are zero or more Command items, and a valid name at index 0. A Command may contain Commands of its own, to any depth, and then it need not have a handler, its help is shown if none of them is named.
 func (r *Command) Validate() error {
+	p := &problems{failFast: true}
+	r.validate(pathOf("Command", *r), p)
+	return p.err()
+}
 
+// validate checks a Command found at the given path, adding the problems it finds to p, and returns true if there were none.
+func (r *Command) validate(path string, p *problems) bool {
 	R := *r
+	valid := true
+	var name string
+	if len(R) > 0 {
+		name, _ = R[0].(string)
+	}
+	// fail records a problem with the element at index, of the given kind, or with the node as a whole if the index is -1
+	fail := func(index int, element string, e error) bool {
+		valid = false
+		return p.add(newValidationError(path, "Command", name, index, element, e))
+	}
 	if len(R) < 1 {
-		return errors.New("empty Command")
+		fail(-1, "", errors.New("empty Command"))
+		return false
 	}
-	s, ok := R[0].(string)
+	name, ok := R[0].(string)
 	if !ok {
-		return fmt.Errorf("first element of Command must be a string")
+		fail(0, "", fmt.Errorf("first element of Command must be a string"))
+		return false
 	}
-	if e := ValidName(s); e != nil {
-		return fmt.Errorf("error in name of Command: %v", e)
+	if e := ValidName(name); e != nil {
+		if fail(0, "", fmt.Errorf("error in name of Command: %v", e)) {
+			return false
+		}
 	}
 	// validSet is an array of 4 elements that represent the presence of the 4 mandatory parts.
 	var validSet [2]bool
 	brief, handler := 0, 1
-	var singleSet [4]bool
-	usage, short, help, examples := 0, 1, 2, 3
-	for i, x := range R[1:] {
+	var singleSet [5]bool
+	usage, short, help, examples, commands := 0, 1, 2, 3, 4
+	// args are the valid Args, in the order they are matched with the operands in the CLI args
+	var args []Arg
+	for i, x := range R {
+		if i == 0 {
+			continue
+		}
 		switch c := x.(type) {
 		case Short:
 			if singleSet[short] {
-				return fmt.Errorf("only one Short field allowed in Command")
+				if fail(i, "Short", fmt.Errorf("only one Short field allowed in Command")) {
+					return false
+				}
 			}
 			singleSet[short] = true
-
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			if e := c.Validate(); e != nil {
+				if fail(i, "Short", e) {
+					return false
+				}
 			}
 		case Brief:
 			if validSet[brief] {
-				return fmt.Errorf("only one Brief permitted in a Command, second found at index %d", i)
+				if fail(i, "Brief", fmt.Errorf("only one Brief permitted in a Command, second found at index %d", i)) {
+					return false
+				}
 			}
 			validSet[brief] = true
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			if e := c.Validate(); e != nil {
+				if fail(i, "Brief", e) {
+					return false
+				}
 			}
 		case Usage:
 			if singleSet[usage] {
-				return fmt.Errorf("only one Usage field allowed in Command")
+				if fail(i, "Usage", fmt.Errorf("only one Usage field allowed in Command")) {
+					return false
+				}
 			}
 			singleSet[usage] = true
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			if e := c.Validate(); e != nil {
+				if fail(i, "Usage", e) {
+					return false
+				}
 			}
 		case Help:
 			if singleSet[help] {
-				return fmt.Errorf("only one Help field allowed in Command")
+				if fail(i, "Help", fmt.Errorf("only one Help field allowed in Command")) {
+					return false
+				}
 			}
 			singleSet[help] = true
-
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			if e := c.Validate(); e != nil {
+				if fail(i, "Help", e) {
+					return false
+				}
 			}
 		case Examples:
 			if singleSet[examples] {
-				return fmt.Errorf("only one Examples field allowed in Command")
+				if fail(i, "Examples", fmt.Errorf("only one Examples field allowed in Command")) {
+					return false
+				}
 			}
 			singleSet[examples] = true
-
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			if e := c.Validate(); e != nil {
+				if fail(i, "Examples", e) {
+					return false
+				}
+			}
+		case Commands:
+			if singleSet[commands] {
+				if fail(i, "Commands", fmt.Errorf("only one Commands field allowed in Command, second found at index %d", i)) {
+					return false
+				}
+			}
+			singleSet[commands] = true
+			if !c.validate(path+"/Commands", p) {
+				if valid = false; p.stopped() {
+					return false
+				}
 			}
 		case Var:
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			vpath := pathOf(path+"/Var", c)
+			if !c.validate(vpath, p) {
+				if valid = false; p.stopped() {
+					return false
+				}
+				continue
+			}
+			if e := checkReserved(c); e != nil {
+				if fail(i, "Var/"+nameOf(c), e) {
+					return false
+				}
 			}
 		case Trigger:
-			e := c.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Command at index %d: %v", i, e)
+			tpath := pathOf(path+"/Trigger", c)
+			if !c.validate(tpath, p) {
+				if valid = false; p.stopped() {
+					return false
+				}
+				continue
+			}
+			if e := checkReserved(c); e != nil {
+				if fail(i, "Trigger/"+nameOf(c), e) {
+					return false
+				}
+			}
+		case Arg:
+			if !c.validate(pathOf(path+"/Arg", c), p) {
+				if valid = false; p.stopped() {
+					return false
+				}
+				continue
+			}
+			element := "Arg/" + nameOf(c)
+			for _, a := range args {
+				if strings.EqualFold(nameOf(a), nameOf(c)) {
+					if fail(i, element, fmt.Errorf("name '%s' is also used by another Arg", nameOf(c))) {
+						return false
+					}
+				}
+			}
+			if len(args) > 0 {
+				last := args[len(args)-1]
+				switch {
+				case hasFlag(last, Variadic{}):
+					if fail(i, element, fmt.Errorf("Arg %s follows the Variadic Arg %s, which must be last", nameOf(c), nameOf(last))) {
+						return false
+					}
+				case hasFlag(last, Optional{}) && !hasFlag(c, Optional{}):
+					if fail(i, element, fmt.Errorf("required Arg %s follows the Optional Arg %s", nameOf(c), nameOf(last))) {
+						return false
+					}
+				}
 			}
+			args = append(args, c)
 		case func(*Tri) int:
 			if validSet[handler] {
-				return fmt.Errorf("only one Handler permitted in a Command, second found at index %d", i)
+				if fail(i, "", fmt.Errorf("only one Handler permitted in a Command, second found at index %d", i)) {
+					return false
+				}
 			}
 			validSet[handler] = true
 			if c == nil {
-				return fmt.Errorf("nil handler in Command found at index %d", i)
+				if fail(i, "", fmt.Errorf("nil handler in Command found at index %d", i)) {
+					return false
+				}
 			}
 		default:
-			return fmt.Errorf("invalid type present in Command: %v", reflect.TypeOf(c))
+			if fail(i, "", fmt.Errorf("invalid type present in Command: %v", reflect.TypeOf(c))) {
+				return false
+			}
 		}
 	}
 	if !validSet[brief] {
-		return errors.New("Brief field must be present")
+		if fail(-1, "", errors.New("Brief field must be present")) {
+			return false
+		}
+	}
+	if len(args) > 0 && singleSet[commands] {
+		if fail(indexOf(R, Commands{}), "Commands", errors.New(
+			"a Command with Args may not contain Commands, as the operands could not be told apart from their names")) {
+			return false
+		}
 	}
-	if !validSet[handler] {
-		return errors.New("Command must have a handler")
+	if !validSet[handler] && !singleSet[commands] {
+		fail(-1, "", errors.New("Command must have a handler, unless it contains Commands"))
 	}
-	return nil
+	return valid
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
 // This validator only triggers the validator on its elements.
 func (r *Commands) Validate() error {
+	p := &problems{failFast: true}
+	r.validate("Commands", p)
+	return p.err()
+}
 
-	R := (*r)
-	for i, x := range R {
-		e := x.Validate()
-		if e != nil {
-			return fmt.Errorf("error in element %d of Commands list: %v", i, e)
+// validate checks the Commands found at the given path, adding the problems it finds to p, and returns true if there were none.
+func (r *Commands) validate(path string, p *problems) bool {
+	valid := true
+	for i, x := range *r {
+		if !x.validate(pathOf(path, x), p) {
+			if valid = false; p.stopped() {
+				return false
+			}
+			continue
+		}
+		if e := checkReserved(x); e != nil {
+			if valid = false; p.add(newValidationError(path, "Commands", "", i, nameOf(x), e)) {
+				return false
+			}
 		}
 	}
-	return nil
+	return valid
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
@@ -245,6 +551,46 @@ func (r *Group) Validate() error {
 	return nil
 }
 
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// A Handler must contain at least one function, and only one each of a parser, formatter and validator, none of which may be nil.
+func (r *Handler) Validate() error {
+
+	R := *r
+	if len(R) < 1 {
+		return errors.New("Handler must contain at least one function")
+	}
+	var found [3]bool
+	parse, format, validate := 0, 1, 2
+	for i, x := range R {
+		var which int
+		switch y := x.(type) {
+		case func(string) (interface{}, error):
+			if y == nil {
+				return fmt.Errorf("Handler contains nil parse function at index %d", i)
+			}
+			which = parse
+		case func(interface{}) string:
+			if y == nil {
+				return fmt.Errorf("Handler contains nil format function at index %d", i)
+			}
+			which = format
+		case func(interface{}) error:
+			if y == nil {
+				return fmt.Errorf("Handler contains nil validate function at index %d", i)
+			}
+			which = validate
+		default:
+			return fmt.Errorf(
+				"Handler element %d is not a parse, format or validate function", i)
+		}
+		if found[which] {
+			return fmt.Errorf("Handler may only contain one of each kind of function, extra found at index %d", i)
+		}
+		found[which] = true
+	}
+	return nil
+}
+
 // Validate checks to ensure the contents of this node type satisfy constraints.
 // Help may only contain one string. It will be parsed as markdown format and possibly can be set to style it with ANSI codes.
 func (r *Help) Validate() error {
@@ -261,156 +607,375 @@ func (r *Help) Validate() error {
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// RunAfter is a simple flag that indicates by existence of an empty value, so it is an error if it has anything inside it.
-func (r *RunAfter) Validate() error {
+// Host is a flag, and may not contain anything.
+func (r *Host) Validate() error {
 
 	R := *r
 	if len(R) > 0 {
-		return errors.New(
-			"RunAfter may not contain anything, empty declaration only")
+		return errors.New("Host may not contain anything, empty declaration only")
 	}
 	return nil
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// Short names contain only a single Rune variable.
-func (r *Short) Validate() error {
+// Max must contain one integer. That it can be placed in the Slot is checked in the Var validator.
+func (r *Max) Validate() error {
 
 	R := *r
 	if len(R) != 1 {
-		return errors.New("Short name item must contain (only) one item")
+		return errors.New("Max must (only) contain one element")
 	}
-	s, ok := R[0].(rune)
-	if !ok {
-		return errors.New("Short's element must be a rune (enclose in '')")
-	}
-	if !(unicode.IsDigit(s) || unicode.IsLetter(s)) {
-		return errors.New("Short element is not a letter or number")
+	if _, ok := R[0].(int); !ok {
+		return errors.New("Max element must be an integer")
 	}
 	return nil
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// Slot may only contain one type of element. The type check is in the Var, here we only ensure the slots contain pointers to the same type, the parser will put the final parsed value in all of them. Multiple variables are permitted here to enable the configuration of more than one application.
-func (r *Slot) Validate() error {
+// Min must contain one integer. That it can be placed in the Slot is checked in the Var validator.
+func (r *Min) Validate() error {
 
 	R := *r
-	var slotTypes []reflect.Type
-	for _, x := range R {
-		slotTypes = append(slotTypes, reflect.TypeOf(x))
+	if len(R) != 1 {
+		return errors.New("Min must (only) contain one element")
 	}
-	for i, x := range slotTypes {
-		if i > 0 {
-			if slotTypes[i] != slotTypes[i-1] {
-				return fmt.Errorf("slot contains more than one type of variable, found %v at index %d", x, i)
-			}
-		}
+	if _, ok := R[0].(int); !ok {
+		return errors.New("Min element must be an integer")
 	}
-	for _, x := range R {
-		if reflect.ValueOf(x).Kind() != reflect.Ptr {
-			return fmt.Errorf("slot contains non-pointer type")
-		}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Optional is a flag, and may not contain anything.
+func (r *Optional) Validate() error {
+
+	R := *r
+	if len(R) > 0 {
+		return errors.New("Optional may not contain anything, empty declaration only")
 	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Override is a flag, and may not contain anything.
+func (r *Override) Validate() error {
 
+	R := *r
+	if len(R) > 0 {
+		return errors.New("Override may not contain anything, empty declaration only")
+	}
 	return nil
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// Terminates is a flag value, and may not contain anything.
-func (r *Terminates) Validate() error {
+// Port is a flag, and may not contain anything.
+func (r *Port) Validate() error {
 
 	R := *r
 	if len(R) > 0 {
-		return errors.New("Terminates type may not contain anything")
+		return errors.New("Port may not contain anything, empty declaration only")
 	}
 	return nil
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// A Tri, the base type, in a declaration must contain a name as first element, a Brief, Version and a Commands item, and only one of each. Also, this and several other subtypes of Tri.
-func (r *Tri) Validate() error {
+// Precision must contain one integer, which is -1, meaning no truncation, or a number of decimal places up to MaxPrecision.
+func (r *Precision) Validate() error {
+
 	R := *r
-	if len(R) < 3 {
-		return errors.New("a Tri must contain at least 3 elements: name, Brief and Version")
+	if len(R) != 1 {
+		return errors.New("Precision must (only) contain one element")
 	}
-	// validSet is an array of 4 elements that represent the presence of the 4 mandatory parts.
-	var validSet [2]bool
-	brief, version := 0, 1
-	var singleSet [3]bool
-	defcom, commands := 0, 1
-	n, ok := R[0].(string)
+	n, ok := R[0].(int)
 	if !ok {
-		return errors.New("first element of a Tri must be a string")
+		return errors.New("Precision element must be an integer")
 	}
-	if e := ValidName(n); e != nil {
-		return fmt.Errorf("error in name of Tri: %v", e)
+	if n < -1 || n > MaxPrecision {
+		return fmt.Errorf("Precision must be between -1 and %d, found %d", MaxPrecision, n)
 	}
+	return nil
+}
 
-	// The mandatory elements also may not be repeated:
-	for i, x := range R {
-		if i == 0 {
-			continue
-		}
-		switch y := x.(type) {
-		case Brief:
-			if validSet[brief] {
-				return fmt.Errorf(
-					"Tri contains more than one Brief, second found at index %d", i)
-			}
-			validSet[brief] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf("Tri field %d: %s", i, e)
-			}
-		case Version:
-			if validSet[version] {
-				return fmt.Errorf(
-					"Tri contains more than one Version, second found at index %d", i)
-			}
-			validSet[version] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf("Tri field %d: %s", i, e)
-			}
-			validSet[version] = true
-		case Commands:
-			if singleSet[commands] {
-				return fmt.Errorf(
-					"Tri contains more than one Commands, second found at index %d", i)
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Required is a flag, and may not contain anything.
+func (r *Required) Validate() error {
+
+	R := *r
+	if len(R) > 0 {
+		return errors.New("Required may not contain anything, empty declaration only")
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// RunAfter is a simple flag that indicates by existence of an empty value, so it is an error if it has anything inside it.
+func (r *RunAfter) Validate() error {
+
+	R := *r
+	if len(R) > 0 {
+		return errors.New(
+			"RunAfter may not contain anything, empty declaration only")
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Sentinels must contain pairs of an integer value and a name, which is a valid name, and no value or name may appear more than once. That the values can be placed in the Slot is checked in the Var validator.
+func (r *Sentinels) Validate() error {
+
+	R := *r
+	if len(R) < 2 {
+		return errors.New("Sentinels may not be empty")
+	}
+	if len(R)%2 != 0 {
+		return errors.New("Sentinels must be in pairs of a value and a name, odd number of elements found")
+	}
+	for i := 0; i < len(R); i += 2 {
+		if _, ok := R[i].(int); !ok {
+			return fmt.Errorf("Sentinels value at index %d is not an integer", i)
+		}
+		name, ok := R[i+1].(string)
+		if !ok {
+			return fmt.Errorf("Sentinels name at index %d is not a string", i+1)
+		}
+		if e := ValidName(name); e != nil {
+			return fmt.Errorf("Sentinels name at index %d is not valid: %v", i+1, e)
+		}
+		for j := 0; j < i; j += 2 {
+			if R[j] == R[i] || strings.EqualFold(R[j+1].(string), name) {
+				return fmt.Errorf("Sentinels pair at index %d has the same value or name as the pair at index %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Short names contain only a single Rune variable.
+func (r *Short) Validate() error {
+
+	R := *r
+	if len(R) != 1 {
+		return errors.New("Short name item must contain (only) one item")
+	}
+	s, ok := R[0].(rune)
+	if !ok {
+		return errors.New("Short's element must be a rune (enclose in '')")
+	}
+	if !(unicode.IsDigit(s) || unicode.IsLetter(s)) {
+		return errors.New("Short element is not a letter or number")
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Slot may only contain one type of element. The type check is in the Var, here we only ensure the slot is not empty and contains non-nil pointers to the same type, the parser will put the final parsed value in all of them. Multiple variables are permitted here to enable the configuration of more than one application.
+func (r *Slot) Validate() error {
+
+	R := *r
+	if len(R) < 1 {
+		return errors.New("Slot must contain at least one pointer")
+	}
+	var slotTypes []reflect.Type
+	for _, x := range R {
+		slotTypes = append(slotTypes, reflect.TypeOf(x))
+	}
+	for i, x := range slotTypes {
+		if i > 0 {
+			if slotTypes[i] != slotTypes[i-1] {
+				return fmt.Errorf("slot contains more than one type of variable, found %v at index %d", x, i)
+			}
+		}
+	}
+	for i, x := range R {
+		v := reflect.ValueOf(x)
+		if v.Kind() != reflect.Ptr {
+			return fmt.Errorf("slot contains non-pointer type")
+		}
+		if v.IsNil() {
+			return fmt.Errorf("slot contains a nil pointer at index %d", i)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Terminates is a flag value, and may not contain anything.
+func (r *Terminates) Validate() error {
+
+	R := *r
+	if len(R) > 0 {
+		return errors.New("Terminates type may not contain anything")
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// A Tri, the base type, in a declaration must contain a name as first element, a Brief, Version and a Commands item, and only one of each. Also, this and several other subtypes of Tri.
+// Once the declaration is found to be valid, the built-in Triggers and Commands are added to the Tri, none of the Vars, Triggers and Commands in the declaration may use their names. A datadir Var is also added unless the declaration has its own at the root, which must have a *string Slot.
+//
+// Validation stops at the first problem found, which is returned as a *ValidationError, use ValidateAll to find all of them at once.
+func (r *Tri) Validate() error {
+	p := &problems{failFast: true}
+	if r.validate(p) {
+		r.addBuiltins()
+	}
+	return p.err()
+}
+
+// ValidateAll checks the declaration in the same way as Validate, but rather than stopping at the first problem, it walks the whole tree and returns every problem found together as DeclarationErrors. Each is prefixed with the path of the node it was found in, such as pod/Commands/ctl/Var/datadir/Default.
+func (r *Tri) ValidateAll() error {
+	p := &problems{}
+	if r.validate(p) {
+		r.addBuiltins()
+	}
+	return p.err()
+}
+
+// validate checks a Tri, adding the problems it finds to p, and returns true if there were none.
+func (r *Tri) validate(p *problems) bool {
+	R := *r
+	path := pathOf("", R)
+	if path == "" {
+		path = "Tri"
+	}
+	valid := true
+	var name string
+	if len(R) > 0 {
+		name, _ = R[0].(string)
+	}
+	// fail records a problem with the element at index, of the given kind, or with the node as a whole if the index is -1
+	fail := func(index int, element string, e error) bool {
+		valid = false
+		return p.add(newValidationError(path, "Tri", name, index, element, e))
+	}
+	if len(R) < 3 {
+		fail(-1, "", errors.New("a Tri must contain at least 3 elements: name, Brief and Version"))
+		return false
+	}
+	// validSet is an array of 4 elements that represent the presence of the 4 mandatory parts.
+	var validSet [2]bool
+	brief, version := 0, 1
+	var singleSet [3]bool
+	defcom, commands := 0, 1
+	name, ok := R[0].(string)
+	if !ok {
+		fail(0, "", errors.New("first element of a Tri must be a string"))
+		return false
+	}
+	if e := ValidName(name); e != nil {
+		if fail(0, "", fmt.Errorf("error in name of Tri: %v", e)) {
+			return false
+		}
+	}
+
+	// The mandatory elements also may not be repeated:
+	for i, x := range R {
+		if i == 0 {
+			continue
+		}
+		switch y := x.(type) {
+		case Brief:
+			if validSet[brief] {
+				if fail(i, "Brief", fmt.Errorf(
+					"Tri contains more than one Brief, second found at index %d", i)) {
+					return false
+				}
+			}
+			validSet[brief] = true
+			if e := y.Validate(); e != nil {
+				if fail(i, "Brief", e) {
+					return false
+				}
+			}
+		case Version:
+			if validSet[version] {
+				if fail(i, "Version", fmt.Errorf(
+					"Tri contains more than one Version, second found at index %d", i)) {
+					return false
+				}
+			}
+			validSet[version] = true
+			if e := y.Validate(); e != nil {
+				if fail(i, "Version", e) {
+					return false
+				}
+			}
+		case Commands:
+			if singleSet[commands] {
+				if fail(i, "Commands", fmt.Errorf(
+					"Tri contains more than one Commands, second found at index %d", i)) {
+					return false
+				}
 			}
 			singleSet[commands] = true
-			e := y.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Tri field %d: %s", i, e)
+			if !y.validate(path+"/Commands", p) {
+				if valid = false; p.stopped() {
+					return false
+				}
 			}
 		case Var:
-			e := y.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Tri at index %d: %v", i, e)
+			vpath := pathOf(path+"/Var", y)
+			if !y.validate(vpath, p) {
+				if valid = false; p.stopped() {
+					return false
+				}
+				continue
+			}
+			if e := checkReserved(y); e != nil {
+				if fail(i, "Var/"+nameOf(y), e) {
+					return false
+				}
+			}
+			if _, ok := slotOf(y).(*string); strings.EqualFold(y[0].(string), dataDir) && !ok {
+				if fail(i, "Var/"+nameOf(y)+"/Slot", fmt.Errorf("the Slot of the %s Var must be a *string", dataDir)) {
+					return false
+				}
 			}
 		case Trigger:
-			e := y.Validate()
-			if e != nil {
-				return fmt.Errorf("error in Tri at index %d: %v", i, e)
+			tpath := pathOf(path+"/Trigger", y)
+			if !y.validate(tpath, p) {
+				if valid = false; p.stopped() {
+					return false
+				}
+				continue
+			}
+			if e := checkReserved(y); e != nil {
+				if fail(i, "Trigger/"+nameOf(y), e) {
+					return false
+				}
 			}
 		case DefaultCommand:
 			if singleSet[defcom] {
-				return fmt.Errorf(
-					"Tri contains more than one DefaultCommand, second found at index %d", i)
+				if fail(i, "DefaultCommand", fmt.Errorf(
+					"Tri contains more than one DefaultCommand, second found at index %d", i)) {
+					return false
+				}
 			}
 			singleSet[defcom] = true
-			e := y.Validate()
-			if e != nil {
-				return fmt.Errorf("Tri field %d: %s", i, e)
+			if e := y.Validate(); e != nil {
+				if fail(i, "DefaultCommand", e) {
+					return false
+				}
+				continue
 			}
 			commname := y[0].(string)
 			// DefaultCommand must match in its name one of the Command items in also present Commands array
 			foundComm := false
 			foundDefComm := false
+			// the built-in Commands are added after validation
+			for _, b := range builtinCommands {
+				if b[0].(string) == commname {
+					foundComm, foundDefComm = true, true
+				}
+			}
 			for _, a := range R {
 				switch c := a.(type) {
 				case Commands:
 					foundComm = true
 					for _, b := range c {
-						if b[0].(string) == commname {
+						if len(b) > 0 && b[0] == commname {
 							foundDefComm = true
 						}
 					}
@@ -418,151 +983,350 @@ func (r *Tri) Validate() error {
 				}
 			}
 			if !foundComm {
-				return errors.New("DefaultCommand with no Commands array present")
+				if fail(i, "DefaultCommand", errors.New("DefaultCommand with no Commands array present")) {
+					return false
+				}
 			} else if !foundDefComm {
-				return errors.New("DefaultCommand found with no matching Command")
+				if fail(i, "DefaultCommand", errors.New("DefaultCommand found with no matching Command")) {
+					return false
+				}
 			}
 
 		default:
-			return fmt.Errorf(
-				"Tri contains an element type it may not contain at index %d", i)
+			if fail(i, "", fmt.Errorf(
+				"Tri contains an element type it may not contain at index %d", i)) {
+				return false
+			}
 		}
 	}
+	if !validSet[brief] {
+		if fail(-1, "", errors.New("Tri is missing its Brief field")) {
+			return false
+		}
+	}
+	if !validSet[version] {
+		if fail(-1, "", errors.New("Tri is missing its Version field")) {
+			return false
+		}
+	}
+	// names can only be compared once every item is known to have one
+	if valid && !r.checkScopes(path, p) {
+		return false
+	}
+	return valid
+}
+
+// scopeItem is a Var, Trigger or Command in a scope of a Tri, with the names it can be referred to by.
+type scopeItem struct {
+	index    int
+	kind     string
+	name     string
+	short    rune
+	hasShort bool
+	override bool
+	builtin  bool
+}
+
+// describe returns a description of a scopeItem for an error message.
+func (s scopeItem) describe() string {
+	if s.builtin {
+		return fmt.Sprintf("the built-in %s %s", s.kind, s.name)
+	}
+	return fmt.Sprintf("the %s %s at index %d", s.kind, s.name, s.index)
+}
+
+// scopeItems returns the Vars and Triggers, or if commands is true, the Commands in a container.
+func scopeItems(container []interface{}, commands bool) (items []scopeItem) {
+	add := func(i int, kind string, node []interface{}) {
+		s, ok := shortOf(node)
+		item := scopeItem{index: i, kind: kind, name: nameOf(node), short: s, hasShort: ok,
+			builtin: isBuiltin(node)}
+		for _, x := range node {
+			if _, ok := x.(Override); ok {
+				item.override = true
+			}
+		}
+		items = append(items, item)
+	}
+	for i, x := range container {
+		switch y := x.(type) {
+		case Var:
+			if !commands {
+				add(i, "Var", y)
+			}
+		case Trigger:
+			if !commands {
+				add(i, "Trigger", y)
+			}
+		case Commands:
+			if commands {
+				for j, c := range y {
+					add(j, "Command", c)
+				}
+			}
+		}
+	}
+	return
+}
+
+// collision returns an error if two items in a scope can be referred to by the same name or Short.
+func collision(a, b scopeItem) error {
 	switch {
-	case !validSet[brief]:
-		return errors.New("Tri is missing its Brief field")
-	case !validSet[version]:
-		return errors.New("Tri is missing its Version field")
+	case strings.EqualFold(a.name, b.name):
+		return fmt.Errorf("name '%s' is also used by %s", a.name, b.describe())
+	case a.hasShort && b.hasShort && a.short == b.short:
+		return fmt.Errorf("Short '%c' is also used by %s", a.short, b.describe())
 	}
 	return nil
 }
 
+// checkScopes checks that no two Vars and Triggers at the root of the Tri, or in one of its Commands, have the same name or Short, and that no two Commands in the same Commands do. An item at the root may not have the name of a Command, as it could not be told apart from it in the configuration file. An item in a Command may only have the same name or Short as one at the root, including the built-in items, or in a Command it is nested in, if it contains an Override, which may only be used for this.
+func (r *Tri) checkScopes(path string, p *problems) bool {
+	valid := true
+	fail := func(e *ValidationError) bool {
+		valid = false
+		return p.add(e)
+	}
+	// the built-in items are placed first, so every item is compared with them, they are added after validation the first time
+	var root, declared []scopeItem
+	for _, x := range scopeItems(*r, false) {
+		if x.builtin {
+			root = append(root, x)
+		} else {
+			declared = append(declared, x)
+		}
+	}
+	for _, b := range builtinTriggers {
+		if !r.hasItem(b) {
+			s, ok := shortOf(b)
+			root = append(root, scopeItem{index: -1, kind: "Trigger", name: nameOf(b), short: s, hasShort: ok, builtin: true})
+		}
+	}
+	if r.dataDirVar() == nil {
+		root = append(root, scopeItem{index: -1, kind: "Var", name: dataDir, short: 'D', hasShort: true, builtin: true})
+	}
+	root = append(root, declared...)
+	commands := scopeItems(*r, true)
+	for _, b := range builtinCommands {
+		if !r.hasCommand(b) {
+			commands = append(commands, scopeItem{index: -1, kind: "Command", name: nameOf(b), builtin: true})
+		}
+	}
+	for i, a := range root {
+		if a.builtin {
+			continue
+		}
+		element := a.kind + "/" + a.name
+		if a.override {
+			if fail(newValidationError(path, "Tri", nameOf(*r), a.index, element,
+				errors.New("Override may only be used in a Command"))) {
+				return false
+			}
+		}
+		for _, b := range root[:i] {
+			if e := collision(a, b); e != nil {
+				if fail(newValidationError(path, "Tri", nameOf(*r), a.index, element, e)) {
+					return false
+				}
+			}
+		}
+		for _, c := range commands {
+			if strings.EqualFold(a.name, c.name) {
+				if fail(newValidationError(path, "Tri", nameOf(*r), a.index, element,
+					fmt.Errorf("name '%s' is also used by %s", a.name, c.describe()))) {
+					return false
+				}
+			}
+		}
+	}
+	for i, a := range commands {
+		if a.builtin {
+			continue
+		}
+		for _, b := range commands[:i] {
+			if e := collision(a, b); e != nil {
+				if fail(newValidationError(path+"/Commands", "Commands", "", a.index, a.name, e)) {
+					return false
+				}
+			}
+		}
+	}
+	// the items of a Command are compared with those of the root and of every Command it is nested in, and the Commands inside it with each other
+	var nested func(cc Commands, path string, outer []scopeItem) bool
+	nested = func(cc Commands, path string, outer []scopeItem) bool {
+		for _, c := range cc {
+			cpath := pathOf(path, c)
+			items := scopeItems(c, false)
+			for i, a := range items {
+				if a.builtin {
+					continue
+				}
+				element := a.kind + "/" + a.name
+				for _, b := range items[:i] {
+					if e := collision(a, b); e != nil {
+						if fail(newValidationError(cpath, "Command", nameOf(c), a.index, element, e)) {
+							return false
+						}
+					}
+				}
+				shadows := false
+				for _, b := range outer {
+					if e := collision(a, b); e != nil {
+						shadows = true
+						if !a.override {
+							e = fmt.Errorf("%v in an enclosing scope, add an Override if this is intended", e)
+							if fail(newValidationError(cpath, "Command", nameOf(c), a.index, element, e)) {
+								return false
+							}
+						}
+					}
+				}
+				if a.override && !shadows {
+					if fail(newValidationError(cpath, "Command", nameOf(c), a.index, element,
+						errors.New("Override used by an item that does not share a name or Short with one in an enclosing scope"))) {
+						return false
+					}
+				}
+			}
+			subcommands := scopeItems(c, true)
+			for i, a := range subcommands {
+				for _, b := range subcommands[:i] {
+					if e := collision(a, b); e != nil {
+						if fail(newValidationError(cpath+"/Commands", "Commands", "", a.index, a.name, e)) {
+							return false
+						}
+					}
+				}
+			}
+			if !nested(commandsOf(c), cpath+"/Commands", append(append([]scopeItem{}, outer...), items...)) {
+				return false
+			}
+		}
+		return true
+	}
+	if !nested(r.commands(), path+"/Commands", root) {
+		return false
+	}
+	return valid
+}
+
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// Trigger must contain (one) name, Brief and Handler, and nothing other than these and Short, Usage, Help, Default, Terminates, RunAfter.
+// Trigger must contain (one) name, Brief and Handler, and nothing other than these and Short, Usage, Help, DefaultOn, Terminates, RunAfter, Group and Override.
 func (r *Trigger) Validate() error {
+	p := &problems{failFast: true}
+	r.validate(pathOf("Trigger", *r), p)
+	return p.err()
+}
 
+// validate checks a Trigger found at the given path, adding the problems it finds to p, and returns true if there were none.
+func (r *Trigger) validate(path string, p *problems) bool {
 	R := *r
+	valid := true
+	var name string
+	if len(R) > 0 {
+		name, _ = R[0].(string)
+	}
+	// fail records a problem with the element at index, of the given kind, or with the node as a whole if the index is -1
+	fail := func(index int, element string, e error) bool {
+		valid = false
+		return p.add(newValidationError(path, "Trigger", name, index, element, e))
+	}
 	if len(R) < 3 {
-		return errors.New(
-			"Trigger must contain a name, Brief and Handler at minimum")
+		fail(-1, "", errors.New(
+			"Trigger must contain a name, Brief and Handler at minimum"))
+		return false
 	}
 	name, ok := R[0].(string)
 	if !ok {
-		return errors.New("first element of Trigger must be the name")
+		fail(0, "", errors.New("first element of Trigger must be the name"))
+		return false
 	} else if e := ValidName(name); e != nil {
-		return fmt.Errorf("Invalid Name in Trigger at index 0: %v", e)
+		if fail(0, "", fmt.Errorf("Invalid Name in Trigger at index 0: %v", e)) {
+			return false
+		}
 	}
 	// validSet is an array that represent the presence of the mandatory parts.
 	var validSet [2]bool
 	brief, handler := 0, 1
-	var singleSet [7]bool
-	short, usage, help, defon, terminates, runafter, group := 0, 1, 2, 3, 4, 5, 6
-	for i, x := range R[1:] {
+	var singleSet [8]bool
+	short, usage, help, defon, terminates, runafter, group, override := 0, 1, 2, 3, 4, 5, 6, 7
+	// single checks that an optional element only appears once and is itself valid
+	single := func(which int, kind string, i int, e error) bool {
+		if singleSet[which] {
+			if fail(i, kind, fmt.Errorf("Trigger may only contain one %s, extra found at index %d", kind, i)) {
+				return true
+			}
+		}
+		singleSet[which] = true
+		return e != nil && fail(i, kind, e)
+	}
+	for i, x := range R {
+		if i == 0 {
+			continue
+		}
 
+		var stop bool
 		switch y := x.(type) {
 
 		case Brief:
 			if validSet[brief] {
-				return fmt.Errorf("Trigger may (only) contain one Brief, second found at index %d", i)
-			} else {
-				validSet[brief] = true
+				if fail(i, "Brief", fmt.Errorf("Trigger may (only) contain one Brief, second found at index %d", i)) {
+					return false
+				}
 			}
+			validSet[brief] = true
 			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
+				stop = fail(i, "Brief", e)
 			}
 
 		case func(*Tri) int:
 			if validSet[handler] {
-				return fmt.Errorf(
-					"Trigger may (only) contain one Handler, second found at index %d", i)
-			} else {
-				validSet[handler] = true
+				if fail(i, "", fmt.Errorf(
+					"Trigger may (only) contain one Handler, second found at index %d", i)) {
+					return false
+				}
 			}
+			validSet[handler] = true
 			if y == nil {
-				return fmt.Errorf("Handler at index %d may not be nil", i)
+				stop = fail(i, "", fmt.Errorf("Handler at index %d may not be nil", i))
 			}
 
 		case Short:
-			if singleSet[short] {
-				return fmt.Errorf("Trigger may only contain one Short, extra found at index %d", i)
-			}
-			singleSet[short] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(short, "Short", i, y.Validate())
 
 		case Usage:
-			if singleSet[usage] {
-				return fmt.Errorf("Trigger may only contain one Usage, extra found at index %d", i)
-			}
-			singleSet[usage] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(usage, "Usage", i, y.Validate())
 
 		case Help:
-			if singleSet[help] {
-				return fmt.Errorf("Trigger may only contain one Help, extra found at index %d", i)
-			}
-			singleSet[help] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(help, "Help", i, y.Validate())
 
 		case DefaultOn:
-			if singleSet[defon] {
-				return fmt.Errorf("Trigger may only contain one DefaultOn, extra found at index %d", i)
-			}
-			singleSet[defon] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(defon, "DefaultOn", i, y.Validate())
 
 		case Terminates:
-			if singleSet[terminates] {
-				return fmt.Errorf("Trigger may only contain one Terminates, extra found at index %d", i)
-			}
-			singleSet[terminates] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(terminates, "Terminates", i, y.Validate())
 
 		case RunAfter:
-			if singleSet[runafter] {
-				return fmt.Errorf("Trigger may only contain one RunAfter, extra found at index %d", i)
-			}
-			singleSet[runafter] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(runafter, "RunAfter", i, y.Validate())
 
 		case Group:
-			if singleSet[group] {
-				return fmt.Errorf(
-					"Trigger may only contain one Group, extra found at index %d", i)
-			}
-			singleSet[group] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Trigger contains invalid element at %d - %s", i, e)
-			}
+			stop = single(group, "Group", i, y.Validate())
+
+		case Override:
+			stop = single(override, "Override", i, y.Validate())
 
 		default:
-			return fmt.Errorf(
-				"found invalid item type at element %d in a Trigger", i)
+			stop = fail(i, "", fmt.Errorf(
+				"found invalid item type at element %d in a Trigger", i))
+		}
+		if stop {
+			return false
 		}
 	}
 	if !(validSet[brief] && validSet[handler]) {
-		return errors.New("Trigger must contain one each of Brief and Handler")
+		fail(-1, "", errors.New("Trigger must contain one each of Brief and Handler"))
 	}
-	return nil
+	return valid
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
@@ -589,150 +1353,351 @@ func (r *Usage) Validate() error {
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
-// Var must contain name, Brief and Slot, and optionally, Short, Usage, Help and Default. The type in the Slot and the Default must be the same.
+// URL may contain strings, the allowed schemes, which start with a letter followed by letters, digits, plus, dash or dot, and no scheme may appear more than once.
+func (r *URL) Validate() error {
+
+	R := *r
+	for i, x := range R {
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("URL element %d is not a string", i)
+		}
+		if !validScheme(s) {
+			return fmt.Errorf("URL element %d '%s' is not a valid scheme", i, s)
+		}
+		for j := 0; j < i; j++ {
+			if strings.EqualFold(R[j].(string), s) {
+				return fmt.Errorf("URL contains the scheme %s more than once, at index %d and %d", s, j, i)
+			}
+		}
+	}
+	return nil
+}
+
+// validScheme returns true if a string is a URL scheme, a letter followed by letters, digits, plus, dash or dot.
+func validScheme(s string) bool {
+	for i, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Variadic is a flag, and may not contain anything.
+func (r *Variadic) Validate() error {
+
+	R := *r
+	if len(R) > 0 {
+		return errors.New("Variadic may not contain anything, empty declaration only")
+	}
+	return nil
+}
+
+// Validate checks to ensure the contents of this node type satisfy constraints.
+// Var must contain name, Brief and Slot, and optionally, Short, Usage, Help, Default, Group, Handler, Precision, Override, Required, Allowed, Min, Max and Sentinels. Min, Max and Sentinels may only be used with an integer Slot, Min may not be more than Max, and the Default must be between them or one of the Sentinels. Address and URL may only be used with a *string or *[]string Slot and Port with a *string or *int Slot, only one of them may be used, Host only with URL, and the Default must be a valid address, port or URL. A Required Var may not have a Default. The type in the Slot and the Default must be the same. Precision may only be used with a float64 Slot, and the Default may not have more decimal places than it allows. A Slot pointing to a type the parser does not handle requires a Handler with a parse function, and a Default must pass the validate function of the Handler, if it has one.
 func (r *Var) Validate() error {
+	p := &problems{failFast: true}
+	r.validate(pathOf("Var", *r), p)
+	return p.err()
+}
 
+// validate checks a Var found at the given path, adding the problems it finds to p, and returns true if there were none.
+func (r *Var) validate(path string, p *problems) bool {
 	R := *r
+	valid := true
+	var name string
+	if len(R) > 0 {
+		name, _ = R[0].(string)
+	}
+	// fail records a problem with the element at index, of the given kind, or with the node as a whole if the index is -1
+	fail := func(index int, element string, e error) bool {
+		valid = false
+		return p.add(newValidationError(path, "Var", name, index, element, e))
+	}
 	if len(R) < 3 {
-		return errors.New(
-			"Var must contain a name, Brief and Slot at minimum")
+		fail(-1, "", errors.New(
+			"Var must contain a name, Brief and Slot at minimum"))
+		return false
 	}
 	name, ok := R[0].(string)
 	if !ok {
-		return errors.New("first element of Var must be the name")
+		fail(0, "", errors.New("first element of Var must be the name"))
+		return false
 	} else if e := ValidName(name); e != nil {
-		return fmt.Errorf("Invalid Name in Var at index 0: %v", e)
+		if fail(0, "", fmt.Errorf("Invalid Name in Var at index 0: %v", e)) {
+			return false
+		}
 	}
 	// validSet is an array that represent the presence of the mandatory parts.
 	var validSet [2]bool
 	brief, slot := 0, 1
 	// singleSet is an array representing the optional elements that may not be more than one inside a Var
-	var singleSet [5]bool
-	short, usage, help, def, group := 0, 1, 2, 3, 4
-	for i, x := range R[1:] {
+	var singleSet [17]bool
+	short, usage, help, def, group, handler, precision, override, required, allowed, min, max, sentinels, address, port, url, host :=
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16
+	// single checks that an optional element only appears once and is itself valid
+	single := func(which int, kind string, i int, e error) bool {
+		if singleSet[which] {
+			if fail(i, kind, fmt.Errorf("Var may only contain one %s, extra found at index %d", kind, i)) {
+				return true
+			}
+		}
+		singleSet[which] = true
+		return e != nil && fail(i, kind, e)
+	}
+	// elementsValid is cleared when an element is invalid, as the checks between elements depend on them
+	elementsValid := true
+	for i, x := range R {
+		if i == 0 {
+			continue
+		}
 
+		var stop bool
+		before := valid
 		switch y := x.(type) {
 
 		case Brief:
 			if validSet[brief] {
-				return fmt.Errorf("Var may must (only) contain one Brief, second found at index %d", i)
-			} else {
-				validSet[brief] = true
+				if fail(i, "Brief", fmt.Errorf("Var may must (only) contain one Brief, second found at index %d", i)) {
+					return false
+				}
 			}
+			validSet[brief] = true
 			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
+				stop = fail(i, "Brief", e)
 			}
 
 		case Short:
-			if singleSet[short] {
-				return fmt.Errorf("Var may only contain one Short, extra found at index %d", i)
-			}
-			singleSet[short] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
-			}
+			stop = single(short, "Short", i, y.Validate())
 
 		case Usage:
-			if singleSet[usage] {
-				return fmt.Errorf("Var may only contain one Usage, extra found at index %d", i)
-			}
-			singleSet[usage] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
-			}
+			stop = single(usage, "Usage", i, y.Validate())
 
 		case Help:
-			if singleSet[help] {
-				return fmt.Errorf("Var may only contain one Help, extra found at index %d", i)
-			}
-			singleSet[help] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
-			}
+			stop = single(help, "Help", i, y.Validate())
 
 		case Default:
-			if singleSet[def] {
-				return fmt.Errorf("Var may only contain one Default, extra found at index %d", i)
-			}
-			singleSet[def] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
+			if stop = single(def, "Default", i, y.Validate()); stop || len(y) != 1 {
+				break
 			}
 			for _, z := range R {
-				s, ok := z.(Slot)
-				if ok {
-					switch s[0].(type) {
-					case *string:
-						_, ok := y[0].(string)
-						if !ok {
-							return errors.New("slot is not same type as default")
-						}
-					case *int:
-						_, ok := y[0].(int)
-						if !ok {
-							return errors.New("slot is not same type as default")
-						}
-					case *uint32:
-						_, ok := y[0].(uint32)
-						if !ok {
-							return errors.New("slot is not same type as default")
-						}
-					case *float64:
-						_, ok := y[0].(float64)
-						if !ok {
-							return errors.New("slot is not same type as default")
-						}
-					case *[]string:
-						_, ok := y[0].([]string)
-						if !ok {
-							return errors.New("slot is not same type as default")
-						}
-					case *time.Duration:
-						_, ok := y[0].(time.Duration)
-						if !ok {
-							return errors.New("slot is not same type as default")
-						}
+				if s, ok := z.(Slot); ok && len(s) > 0 && reflect.ValueOf(s[0]).Kind() == reflect.Ptr {
+					if _, e := convertDefault(y[0], reflect.TypeOf(s[0]).Elem()); e != nil {
+						stop = fail(i, "Default", e)
 					}
-					// *s[0] = *y[0]
 				}
 			}
 
 		case Slot:
 			if validSet[slot] {
-				return fmt.Errorf("Var may only contain one Slot, extra found at index %d", i)
+				if fail(i, "Slot", fmt.Errorf("Var may only contain one Slot, extra found at index %d", i)) {
+					return false
+				}
 			}
 			validSet[slot] = true
 			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
+				stop = fail(i, "Slot", e)
 			}
 
 		case Group:
-			if singleSet[group] {
-				return fmt.Errorf(
-					"Var may only contain one Group, extra found at index %d", i)
-			}
-			singleSet[group] = true
-			if e := y.Validate(); e != nil {
-				return fmt.Errorf(
-					"Var contains invalid element at %d - %s", i, e)
-			}
+			stop = single(group, "Group", i, y.Validate())
+
+		case Handler:
+			stop = single(handler, "Handler", i, y.Validate())
+
+		case Precision:
+			stop = single(precision, "Precision", i, y.Validate())
+
+		case Override:
+			stop = single(override, "Override", i, y.Validate())
+
+		case Required:
+			stop = single(required, "Required", i, y.Validate())
+
+		case Allowed:
+			stop = single(allowed, "Allowed", i, y.Validate())
+
+		case Min:
+			stop = single(min, "Min", i, y.Validate())
+
+		case Max:
+			stop = single(max, "Max", i, y.Validate())
+
+		case Sentinels:
+			stop = single(sentinels, "Sentinels", i, y.Validate())
+
+		case Address:
+			stop = single(address, "Address", i, y.Validate())
+
+		case Port:
+			stop = single(port, "Port", i, y.Validate())
+
+		case URL:
+			stop = single(url, "URL", i, y.Validate())
+
+		case Host:
+			stop = single(host, "Host", i, y.Validate())
+
 		default:
-			return fmt.Errorf(
-				"found invalid item type at element %d in a Var", i)
+			stop = fail(i, "", fmt.Errorf(
+				"found invalid item type at element %d in a Var", i))
+		}
+		if stop {
+			return false
+		}
+		if before && !valid {
+			elementsValid = false
 		}
 	}
 	if !(validSet[brief] && validSet[slot]) {
-		return errors.New("Var must contain one each of Brief and Slot")
+		if fail(-1, "", errors.New("Var must contain one each of Brief and Slot")) {
+			return false
+		}
+	}
+	if !elementsValid {
+		return false
+	}
+	if singleSet[address] && singleSet[port] || singleSet[url] && (singleSet[address] || singleSet[port]) {
+		if fail(-1, "", fmt.Errorf("Var %s may only be one of an Address, a Port or a URL", name)) {
+			return false
+		}
+	}
+	if singleSet[host] && !singleSet[url] {
+		if fail(indexOf(R, Host{}), "Host", fmt.Errorf("Var %s has a Host but is not a URL", name)) {
+			return false
+		}
 	}
-	// TODO: check that Default value can be assigned to dereferenced Slot variable
+	if singleSet[required] && singleSet[def] {
+		if fail(indexOf(R, Required{}), "Required", fmt.Errorf(
+			"Var %s is Required and may not have a Default, it must be given a value", name)) {
+			return false
+		}
+	}
+	checkValue(R, "Var", fail)
+	return valid
+}
 
-	return nil
+// checkValue checks the elements of a Var or Arg that depend on each other, whose elements are each valid, that its Slot can be parsed, its Precision is for a float64, its Allowed values are of the type of its Slot, as are its Min, Max and Sentinels, which need an integer Slot, and its Default has no more decimal places than its precision, is accepted by its Handler, is one of its Allowed values and is within its Min and Max or one of its Sentinels. Without a Default, the zero value must be, unless it is Required. It stops at the first problem if fail returns true.
+func checkValue(node []interface{}, kind string, fail func(index int, element string, e error) bool) {
+	name := nameOf(node)
+	parse, _, validate := valueHandlers(node)
+	if s := slotOf(node); s != nil && !parsable(s) && parse == nil {
+		if fail(indexOf(node, Slot{}), "Slot", fmt.Errorf(
+			"%s %s has a Slot of type %T which requires a Handler with a parse function", kind, name, s)) {
+			return
+		}
+	}
+	if _, ok := slotOf(node).(*float64); indexOf(node, Precision{}) >= 0 && !ok {
+		if fail(indexOf(node, Precision{}), "Precision", fmt.Errorf("%s %s has a Precision but its Slot is not a *float64", kind, name)) {
+			return
+		}
+	}
+	if _, e := allowedValues(node); e != nil {
+		if fail(indexOf(node, Allowed{}), "Allowed", fmt.Errorf("Allowed of %s %s is not valid: %v", kind, name, e)) {
+			return
+		}
+	}
+	if !checkRange(node, kind, fail) {
+		return
+	}
+	for _, x := range []struct {
+		example interface{}
+		slots   string
+		ok      bool
+	}{
+		{Address{}, "*string or *[]string", isSlot(node, new(string), new([]string))},
+		{Port{}, "*string or *int", isSlot(node, new(string), new(int))},
+		{URL{}, "*string or *[]string", isSlot(node, new(string), new([]string))},
+	} {
+		if i := indexOf(node, x.example); i >= 0 && !x.ok {
+			element := reflect.TypeOf(x.example).Name()
+			if fail(i, element, fmt.Errorf("%s %s has a %s but its Slot is not a %s", kind, name, element, x.slots)) {
+				return
+			}
+		}
+	}
+	hasDefault := indexOf(node, Default{}) >= 0
+	if d, ok := defaultValue(node).(float64); ok && hasDefault {
+		if t, e := parseDecimal(strconv.FormatFloat(d, 'f', -1, 64), precisionOf(node)); e != nil || t != d {
+			if fail(indexOf(node, Default{}), "Default", fmt.Errorf(
+				"Default of %s %s has more than %d decimal places", kind, name, precisionOf(node))) {
+				return
+			}
+		}
+	}
+	if validate != nil && hasDefault {
+		if e := validate(defaultValue(node)); e != nil {
+			if fail(indexOf(node, Default{}), "Default", fmt.Errorf("Default of %s %s is not valid: %v", kind, name, e)) {
+				return
+			}
+		}
+	}
+	if d := indexOf(node, Default{}); d >= 0 && slotOf(Var(node)) != nil {
+		if _, e := normalise(node, node[d].(Default)[0]); e != nil {
+			if fail(d, "Default", fmt.Errorf("Default of %s %s is not valid: %v", kind, name, e)) {
+				return
+			}
+		}
+	}
+	if hasDefault {
+		if e := checkAllowed(node, defaultValue(node)); e != nil {
+			if fail(indexOf(node, Default{}), "Default", fmt.Errorf("Default of %s %s is not allowed: %v", kind, name, e)) {
+				return
+			}
+		}
+		if e := checkBounds(node, defaultValue(node)); e != nil {
+			fail(indexOf(node, Default{}), "Default", fmt.Errorf("Default of %s %s is out of range: %v", kind, name, e))
+		}
+	} else if e := checkBounds(node, defaultValue(node)); e != nil && !hasFlag(node, Required{}) {
+		fail(-1, "", fmt.Errorf("%s %s has no Default and its zero value is out of range, it needs a Default or Required: %v", kind, name, e))
+	}
+}
+
+// checkRange checks that the Min, Max and Sentinels of a Var or Arg are only used with an integer Slot, their values can be placed in it, and Min is not more than Max. It returns false if fail returns true for a problem it finds.
+func checkRange(node []interface{}, kind string, fail func(index int, element string, e error) bool) bool {
+	name := nameOf(node)
+	s := slotOf(Var(node))
+	if s == nil {
+		return true
+	}
+	for _, example := range []interface{}{Min{}, Max{}, Sentinels{}} {
+		i := indexOf(node, example)
+		if i < 0 {
+			continue
+		}
+		element := reflect.TypeOf(example).Name()
+		if !isInteger(reflect.TypeOf(s).Elem()) {
+			if fail(i, element, fmt.Errorf("%s %s has a %s but its Slot is not an integer type", kind, name, element)) {
+				return false
+			}
+			continue
+		}
+		var values []interface{}
+		if x, ok := node[i].(Sentinels); ok {
+			for j := 0; j < len(x); j += 2 {
+				values = append(values, x[j])
+			}
+		} else {
+			values = append(values, reflect.ValueOf(node[i]).Index(0).Interface())
+		}
+		for _, x := range values {
+			if _, e := convertDefault(x, reflect.TypeOf(s).Elem()); e != nil {
+				if fail(i, element, fmt.Errorf("%s of %s %s cannot be placed in its Slot: %v", element, kind, name, e)) {
+					return false
+				}
+			}
+		}
+	}
+	min, hasMin := bound(node, Min{})
+	max, hasMax := bound(node, Max{})
+	if hasMin && hasMax && min > max {
+		if fail(indexOf(node, Min{}), "Min", fmt.Errorf("Min of %s %s is more than its Max", kind, name)) {
+			return false
+		}
+	}
+	return true
 }
 
 // Validate checks to ensure the contents of this node type satisfy constraints.
@@ -771,6 +1736,114 @@ func (r *Version) Validate() error {
 	return nil
 }
 
+// ValidationError is a problem found in a declaration by Validate or ValidateAll, in the node at Path, which is made of the types and names of the nodes leading to it from the root, such as pod/Commands/ctl/Var/datadir, followed by the type of the element with the problem, if it is not with the node as a whole.
+type ValidationError struct {
+	// Path is the path from the root of the declaration to the problem, eg. pod/Commands/ctl/Var/datadir/Default.
+	Path string
+	// Node is the type of the node the problem was found in, such as Var, and Name is its name, if it has one.
+	Node, Name string
+	// Index is the position in the node of the element with the problem, or -1 if it is with the node as a whole, such as a missing element.
+	Index int
+	// Err describes the problem.
+	Err error
+}
+
+// newValidationError returns a ValidationError for a problem in the node at path, with its element at index, of the kind given by element, which is added to the path.
+func newValidationError(path, node, name string, index int, element string, e error) *ValidationError {
+	if element != "" {
+		path += "/" + element
+	}
+	return &ValidationError{Path: path, Node: node, Name: name, Index: index, Err: e}
+}
+
+// Error returns the path of the problem followed by its description.
+func (v *ValidationError) Error() string {
+	return v.Path + ": " + v.Err.Error()
+}
+
+// Unwrap returns the error describing the problem, which is the error returned by the validator of the element, if it was found in one.
+func (v *ValidationError) Unwrap() error {
+	return v.Err
+}
+
+// DeclarationErrors is the list of problems found in a declaration by ValidateAll.
+type DeclarationErrors []*ValidationError
+
+// Error lists the problems in a DeclarationErrors, one per line.
+func (d DeclarationErrors) Error() string {
+	lines := make([]string, len(d))
+	for i, e := range d {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the problems in a DeclarationErrors, so errors.As can find a ValidationError in it.
+func (d DeclarationErrors) Unwrap() []error {
+	errs := make([]error, len(d))
+	for i, e := range d {
+		errs[i] = e
+	}
+	return errs
+}
+
+// problems collects the errors found while validating a declaration. If failFast is set validation stops at the first.
+type problems struct {
+	errs     DeclarationErrors
+	failFast bool
+}
+
+// add records a problem, and returns true if validation should stop.
+func (p *problems) add(e *ValidationError) bool {
+	p.errs = append(p.errs, e)
+	return p.failFast
+}
+
+// stopped returns true if a problem has been found and validation should stop.
+func (p *problems) stopped() bool {
+	return p.failFast && len(p.errs) > 0
+}
+
+// err returns the first problem if failFast is set, or all of them, or nil if there were none.
+func (p *problems) err() error {
+	switch {
+	case len(p.errs) < 1:
+		return nil
+	case p.failFast:
+		return p.errs[0]
+	}
+	return p.errs
+}
+
+// hasFlag returns true if a Tri node contains an element of the same type as the example given, such as Optional{}.
+func hasFlag(node []interface{}, example interface{}) bool {
+	return indexOf(node, example) >= 0
+}
+
+// indexOf returns the index of the first element of a node with the same type as the example given, such as Slot{}, or -1 if there is none.
+func indexOf(node []interface{}, example interface{}) int {
+	t := reflect.TypeOf(example)
+	for i, x := range node {
+		if reflect.TypeOf(x) == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// pathOf returns the path of a Var, Trigger or Command inside the node at the given path, which is followed by its name if it has one.
+func pathOf(path string, node []interface{}) string {
+	if len(node) > 0 {
+		if s, ok := node[0].(string); ok {
+			if path == "" {
+				return s
+			}
+			return path + "/" + s
+		}
+	}
+	return path
+}
+
 // ValidName checks that a Tri name element that should be a name only contains letters.
 func ValidName(s string) error {
 
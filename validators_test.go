@@ -1,6 +1,8 @@
 package tri
 
 import (
+	"errors"
+	"strings"
 	"time"
 	"testing"
 )
@@ -9,6 +11,83 @@ func MakeTestHandler() func(*Tri) int {
 	return func(*Tri) int { return 0 }
 }
 
+func TestAddress(t *testing.T) {
+	for i, x := range []Address{{1, 2}, {"11048"}, {0}, {65536}} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Address %d", i)
+		}
+	}
+	// no error
+	for i, x := range []Address{{}, {11048}} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid Address %d: %v", i, e)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	for i, x := range []Allowed{
+		// at least one value
+		{},
+		// no nil values
+		{"mainnet", nil},
+		// no value twice
+		{"mainnet", "testnet", "mainnet"},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Allowed %d", i)
+		}
+	}
+	// no error!
+	ta := Allowed{"mainnet", "testnet"}
+	if e := ta.Validate(); e != nil {
+		t.Error("validator rejected valid Allowed:", e)
+	}
+}
+
+func TestArg(t *testing.T) {
+	var address string
+	var files []string
+	var amount float64
+	for i, x := range []Arg{
+		// name, Brief and Slot are required
+		{"address", Brief{"brief"}},
+		{"address", Slot{&address}, Help{"help"}},
+		{1, Brief{"brief"}, Slot{&address}},
+		{"address1", Brief{"brief"}, Slot{&address}},
+		// only one of each
+		{"address", Brief{"brief"}, Brief{"brief"}, Slot{&address}},
+		{"address", Brief{"brief"}, Slot{&address}, Optional{}, Optional{}},
+		// invalid elements
+		{"address", Brief{"brief"}, Slot{&address}, Optional{1}},
+		{"address", Brief{"brief"}, Slot{&address}, Short{'a'}},
+		// Default that cannot be placed in the Slot
+		{"address", Brief{"brief"}, Slot{&address}, Default{1}},
+		// Variadic needs a list
+		{"address", Brief{"brief"}, Slot{&address}, Variadic{}},
+		// Precision needs a float64
+		{"address", Brief{"brief"}, Slot{&address}, Precision{2}},
+		// Allowed values of the type of the Slot, which the Default is one of
+		{"address", Brief{"brief"}, Slot{&address}, Allowed{1}},
+		{"address", Brief{"brief"}, Slot{&address}, Optional{}, Allowed{"aaa"}, Default{"bbb"}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Arg %d", i)
+		}
+	}
+	// no error!
+	for i, x := range []Arg{
+		{"address", Brief{"brief"}, Slot{&address}},
+		{"files", Brief{"brief"}, Help{"help"}, Optional{}, Variadic{}, Default{[]string{"a"}}, Slot{&files}},
+		{"amount", Brief{"brief"}, Precision{2}, Default{1.5}, Slot{&amount}},
+		{"address", Brief{"brief"}, Slot{&address}, Optional{}, Allowed{"aaa", "bbb"}, Default{"bbb"}},
+	} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid Arg %d: %v", i, e)
+		}
+	}
+}
+
 func TestBrief(t *testing.T) {
 
 	// one item only
@@ -175,6 +254,52 @@ func TestCommand(t *testing.T) {
 	if e := tc20.Validate(); e != nil {
 		t.Error("validator rejected valid Command")
 	}
+	// no more than one Commands
+	tc21 := Command{"name", Brief{""}, MakeTestHandler(), Commands{}, Commands{}}
+	if e := tc21.Validate(); e == nil {
+		t.Error("validator accepted more than one Commands")
+	}
+	// nested Commands are validated, with their path
+	tc22 := Command{"name", Brief{""},
+		Commands{
+			{"sub", Brief{""}, Commands{{"deeper", Brief{""}}}},
+		},
+	}
+	e := tc22.Validate()
+	var ve *ValidationError
+	if e == nil || !errors.As(e, &ve) || ve.Path != "Command/name/Commands/sub/Commands/deeper" {
+		t.Error("validator accepted invalid nested Command, or reported it without its path:", e)
+	}
+	// a Command holding Commands needs no handler
+	tc23 := Command{"name", Brief{""},
+		Commands{
+			{"sub", Brief{""}, Commands{{"deeper", Brief{""}, MakeTestHandler()}}},
+		},
+	}
+	if e := tc23.Validate(); e != nil {
+		t.Error("validator rejected Command with nested Commands and no handler:", e)
+	}
+	// Args are in an order they can be matched with operands, and the Command has no Commands
+	var a, b string
+	var c []string
+	required := Arg{"address", Brief{""}, Slot{&a}}
+	optional := Arg{"label", Brief{""}, Optional{}, Slot{&b}}
+	variadic := Arg{"files", Brief{""}, Optional{}, Variadic{}, Slot{&c}}
+	for i, x := range []Command{
+		{"name", Brief{""}, MakeTestHandler(), optional, required},
+		{"name", Brief{""}, MakeTestHandler(), variadic, optional},
+		{"name", Brief{""}, MakeTestHandler(), required, Arg{"Address", Brief{""}, Optional{}, Slot{&b}}},
+		{"name", Brief{""}, required, Commands{{"sub", Brief{""}, MakeTestHandler()}}},
+		{"name", Brief{""}, MakeTestHandler(), Arg{"address", Brief{""}}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted Command with invalid Args %d", i)
+		}
+	}
+	tc24 := Command{"name", Brief{""}, MakeTestHandler(), required, optional, variadic}
+	if e := tc24.Validate(); e != nil {
+		t.Error("validator rejected Command with valid Args:", e)
+	}
 }
 func TestCommands(t *testing.T) {
 	tcc1 := Commands{Command{"name", Brief{""}, MakeTestHandler(), 1}}
@@ -361,6 +486,41 @@ func TestGroup(t *testing.T) {
 
 }
 
+func TestHandler(t *testing.T) {
+
+	parse := func(s string) (interface{}, error) { return s, nil }
+	format := func(v interface{}) string { return "" }
+	validate := func(v interface{}) error { return nil }
+
+	// contains at least one function
+	th1 := Handler{}
+	if e := th1.Validate(); e == nil {
+		t.Error("validator accepted empty Handler")
+	}
+	// contains only functions of the handler types
+	th2 := Handler{parse, MakeTestHandler()}
+	if e := th2.Validate(); e == nil {
+		t.Error("validator accepted function that is not a handler")
+	}
+	// contains only one of each
+	th3 := Handler{validate, format, validate}
+	if e := th3.Validate(); e == nil {
+		t.Error("validator accepted more than one validate function")
+	}
+	// functions are not nil
+	var nilparse func(string) (interface{}, error)
+	th4 := Handler{nilparse}
+	if e := th4.Validate(); e == nil {
+		t.Error("validator accepted nil function")
+	}
+	// no error!
+	th5 := Handler{format, validate, parse}
+	if e := th5.Validate(); e != nil {
+		t.Error("validator rejected valid Handler")
+	}
+
+}
+
 func TestHelp(t *testing.T) {
 
 	// contains only one element
@@ -391,6 +551,127 @@ func TestHelp(t *testing.T) {
 
 }
 
+func TestHost(t *testing.T) {
+
+	// may not contain anything
+	th1 := Host{""}
+	if e := th1.Validate(); e == nil {
+		t.Error("validator accepted content in Host")
+	}
+	// no error
+	th2 := Host{}
+	if e := th2.Validate(); e != nil {
+		t.Error("validator rejected valid Host")
+	}
+
+}
+
+func TestMax(t *testing.T) {
+	for i, x := range []Max{{}, {1, 2}, {"1"}} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Max %d", i)
+		}
+	}
+	// no error
+	tm := Max{64}
+	if e := tm.Validate(); e != nil {
+		t.Error("validator rejected valid Max:", e)
+	}
+}
+
+func TestMin(t *testing.T) {
+	for i, x := range []Min{{}, {1, 2}, {1.5}} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Min %d", i)
+		}
+	}
+	// no error
+	tm := Min{-8}
+	if e := tm.Validate(); e != nil {
+		t.Error("validator rejected valid Min:", e)
+	}
+}
+
+func TestOptional(t *testing.T) {
+
+	// may not contain anything
+	to1 := Optional{""}
+	if e := to1.Validate(); e == nil {
+		t.Error("validator accepted content in Optional")
+	}
+	// no error
+	to2 := Optional{}
+	if e := to2.Validate(); e != nil {
+		t.Error("validator rejected valid Optional")
+	}
+
+}
+
+func TestPort(t *testing.T) {
+
+	// may not contain anything
+	tp1 := Port{11048}
+	if e := tp1.Validate(); e == nil {
+		t.Error("validator accepted content in Port")
+	}
+	// no error
+	tp2 := Port{}
+	if e := tp2.Validate(); e != nil {
+		t.Error("validator rejected valid Port")
+	}
+
+}
+
+func TestPrecision(t *testing.T) {
+
+	// contains only one element
+	tp1 := Precision{1, 2}
+	if e := tp1.Validate(); e == nil {
+		t.Error("validator accepted more than one element")
+	}
+	tp2 := Precision{}
+	if e := tp2.Validate(); e == nil {
+		t.Error("validator accepted no elements")
+	}
+	// element is an integer
+	tp3 := Precision{"8"}
+	if e := tp3.Validate(); e == nil {
+		t.Error("validator accepted non integer element")
+	}
+	// within range
+	tp4 := Precision{MaxPrecision + 1}
+	if e := tp4.Validate(); e == nil {
+		t.Error("validator accepted out of range Precision")
+	}
+	tp4 = Precision{-2}
+	if e := tp4.Validate(); e == nil {
+		t.Error("validator accepted out of range Precision")
+	}
+	// no error!
+	for _, n := range []int{-1, 0, 2} {
+		tp5 := Precision{n}
+		if e := tp5.Validate(); e != nil {
+			t.Error("validator rejected valid Precision", n)
+		}
+	}
+
+}
+
+func TestRequired(t *testing.T) {
+
+	// may not contain anything
+	tr1 := Required{""}
+	if e := tr1.Validate(); e == nil {
+		t.Error("validator accepted content in Required")
+	}
+	// no error
+	tr2 := Required{}
+	if e := tr2.Validate(); e != nil {
+		t.Error("validator rejected valid Required")
+	}
+
+}
+
 func TestRunAfter(t *testing.T) {
 
 	// may not contain anything
@@ -410,6 +691,113 @@ func TestRunAfter(t *testing.T) {
 
 }
 
+func TestScopes(t *testing.T) {
+	var a, b int
+	v := func(name string, x ...interface{}) Var {
+		return append(Var{name, Brief{"brief"}, Slot{&a}}, x...)
+	}
+	tr := func(name string, x ...interface{}) Trigger {
+		return append(Trigger{name, Brief{"brief"}, MakeTestHandler()}, x...)
+	}
+	c := func(name string, x ...interface{}) Command {
+		return append(Command{name, Brief{"brief"}, MakeTestHandler()}, x...)
+	}
+	tri := func(x ...interface{}) Tri {
+		return append(Tri{"appname", Brief{"brief"}, Version{0, 1, 1}}, x...)
+	}
+	for i, x := range []Tri{
+		// same name at the root, in any case
+		tri(v("port"), tr("Port")),
+		// same Short at the root
+		tri(v("port", Short{'p'}), v("peers", Short{'p'})),
+		// root item with the name of a Command
+		tri(v("ctl"), Commands{c("ctl")}),
+		// Commands with the same name or Short
+		tri(Commands{c("ctl"), c("CTL")}),
+		tri(Commands{c("ctl", Short{'c'}), c("cli", Short{'c'})}),
+		// same name or Short in a Command
+		tri(Commands{c("ctl", v("port"), tr("port"))}),
+		tri(Commands{c("ctl", v("port", Short{'p'}), tr("peers", Short{'p'}))}),
+		// Command item shadowing the root without an Override
+		tri(v("port"), Commands{c("ctl", v("port"))}),
+		tri(v("port", Short{'p'}), Commands{c("ctl", v("peers", Short{'p'}))}),
+		// shadowing built-in items
+		tri(v("lines", Short{'I'})),
+		tri(Commands{c("ctl", tr("datadir"))}),
+		tri(Commands{c("ctl", v("peers", Short{'S'}))}),
+		// Override at the root, or where nothing is shadowed
+		tri(v("port", Override{})),
+		tri(v("port"), Commands{c("ctl", v("peers", Override{}))}),
+		// only one Override
+		tri(v("port"), Commands{c("ctl", v("port", Override{}, Override{}))}),
+		// nested Commands with the same name, and nested items shadowing an enclosing Command
+		tri(Commands{c("wallet", Commands{c("account"), c("Account")})}),
+		tri(Commands{c("wallet", v("port"), Commands{c("account", v("port"))})}),
+		tri(v("port"), Commands{c("wallet", Commands{c("account", v("port"))})}),
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted ambiguous declaration %d", i)
+		}
+	}
+	// the problem is at the item that repeats the name, in the node where it was found
+	var ve *ValidationError
+	x := tri(v("port"), Commands{c("ctl", v("peers"), tr("Peers"))})
+	if !errors.As(x.Validate(), &ve) || ve.Path != "appname/Commands/ctl/Trigger/Peers" ||
+		ve.Node != "Command" || ve.Name != "ctl" || ve.Index != 4 {
+		t.Errorf("validator did not describe the repeated name: %#v", ve)
+	}
+	// no error!
+	for i, x := range []Tri{
+		tri(v("port", Short{'p'}), tr("reindex", Short{'r'}),
+			Var{"peers", Brief{"brief"}, Short{'P'}, Slot{&b}},
+			Commands{
+				c("ctl", Short{'c'}, v("port", Short{'p'}, Override{}), tr("wallet", Short{'w'})),
+				c("node", Short{'n'}, v("port", Override{}), tr("wallet", Short{'w'})),
+			}),
+		tri(Commands{c("ctl", v("datadir", Override{}), v("inits", Short{'I'}, Override{}))}),
+		tri(v("port"), Commands{
+			c("wallet", v("peers"), Commands{
+				c("account", v("peers", Override{}), v("port", Override{}), Commands{c("create")}),
+				c("send"),
+			}),
+			c("account"),
+		}),
+	} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid declaration %d: %v", i, e)
+		}
+		// and again, once the built-in items are added
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid declaration %d on revalidation: %v", i, e)
+		}
+	}
+
+}
+
+func TestSentinels(t *testing.T) {
+	for i, x := range []Sentinels{
+		// pairs of an integer and a valid name
+		{},
+		{-1},
+		{-1, "all", 0},
+		{"all", -1},
+		{-1, 0},
+		{-1, "a1"},
+		// no value or name twice
+		{-1, "all", -1, "every"},
+		{-1, "all", 0, "ALL"},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Sentinels %d", i)
+		}
+	}
+	// no error
+	ts := Sentinels{-1, "all", 0, "none"}
+	if e := ts.Validate(); e != nil {
+		t.Error("validator rejected valid Sentinels:", e)
+	}
+}
+
 func TestShort(t *testing.T) {
 
 	// contains only one element
@@ -461,6 +849,23 @@ func TestSlot(t *testing.T) {
 		t.Error("validator accepted heteregenous types")
 	}
 
+	// slots are not empty
+	ts4 := Slot{}
+	if e = ts4.Validate(); e == nil {
+		t.Error("validator accepted empty Slot")
+	}
+
+	// slots do not contain nil
+	var p *int
+	ts5 := Slot{&a, p}
+	if e = ts5.Validate(); e == nil {
+		t.Error("validator accepted nil pointer")
+	}
+	ts6 := Slot{nil}
+	if e = ts6.Validate(); e == nil {
+		t.Error("validator accepted nil")
+	}
+
 	// no error!
 	ts3 := Slot{&a, &c}
 	e = ts3.Validate()
@@ -603,6 +1008,93 @@ func TestTri(t *testing.T) {
 
 }
 
+func TestValidateAll(t *testing.T) {
+	var port int
+	var dir string
+	tv := Tri{"pod", Brief{"brief"}, Version{0, 1, 1},
+		Var{"port", Brief{"brief"}, Default{"x"}, Slot{&port}},
+		Trigger{"wallet", Brief{"brief"}},
+		Commands{
+			{"ctl", Brief{"brief"}, MakeTestHandler(),
+				Var{"datadir", Brief{"brief"}, Default{1, 2}, Slot{&dir}},
+				Group{"aaaa"},
+			},
+		},
+	}
+	// every problem is returned, each with its path
+	e := tv.ValidateAll()
+	errs, ok := e.(DeclarationErrors)
+	if !ok {
+		t.Fatal("validator did not return DeclarationErrors:", e)
+	}
+	paths := []string{
+		"pod/Var/port/Default: ",
+		"pod/Trigger/wallet: ",
+		"pod/Commands/ctl/Var/datadir/Default: ",
+		"pod/Commands/ctl: ",
+	}
+	if len(errs) != len(paths) {
+		t.Fatalf("validator found %d problems, expected %d:\n%v", len(errs), len(paths), e)
+	}
+	for i, x := range paths {
+		if !strings.HasPrefix(errs[i].Error(), x) {
+			t.Errorf("problem %d does not have path %s: %v", i, x, errs[i])
+		}
+	}
+	if strings.Count(e.Error(), "\n") != len(paths)-1 {
+		t.Error("problems are not listed one per line")
+	}
+	// Validate stops at the first, which can be found with errors.As
+	if e = tv.Validate(); e == nil || e.Error() != errs[0].Error() {
+		t.Error("validator did not stop at the first problem:", e)
+	}
+	var ve *ValidationError
+	if !errors.As(e, &ve) || ve.Node != "Var" || ve.Name != "port" || ve.Index != 2 ||
+		ve.Path != "pod/Var/port/Default" {
+		t.Errorf("validator did not describe the problem: %#v", ve)
+	}
+	if !errors.As(tv.ValidateAll(), &ve) || ve.Path != "pod/Var/port/Default" {
+		t.Error("ValidationError not found in DeclarationErrors")
+	}
+	// problems with the node as a whole have no index, those in the Commands have the index of the Command
+	if ve = errs[1]; ve.Node != "Trigger" || ve.Name != "wallet" || ve.Index != -1 {
+		t.Errorf("validator did not describe the problem: %#v", ve)
+	}
+	if ve = errs[3]; ve.Node != "Command" || ve.Name != "ctl" || ve.Index != 4 {
+		t.Errorf("validator did not describe the problem: %#v", ve)
+	}
+	// a node without a Slot is reported rather than its Default being checked against it
+	tm := Tri{"pod", Brief{"brief"}, Version{0, 1, 1},
+		Var{"port", Brief{"brief"}, Default{1}},
+		Commands{
+			{"cmd", Brief{"brief"}, Arg{"name", Brief{"brief"}, Default{"x"}}, MakeTestHandler()},
+		},
+	}
+	if errs, ok = tm.ValidateAll().(DeclarationErrors); !ok || len(errs) != 2 ||
+		!strings.HasPrefix(errs[1].Error(), "pod/Commands/cmd/Arg/name: ") {
+		t.Error("nodes without a Slot were not reported:", errs)
+	}
+	// indexes are the position in the node, counting the name
+	tv1 := Var{"aaaa", Brief{"aaaa"}, Short{'a'}, Short{'b'}, Slot{&port}}
+	if !errors.As(tv1.Validate(), &ve) || ve.Index != 3 || ve.Path != "Var/aaaa/Short" ||
+		!strings.HasSuffix(ve.Error(), "index 3") {
+		t.Errorf("validator did not give the index of the problem: %#v", ve)
+	}
+	// built-in items are only added to a valid declaration
+	for _, x := range tv {
+		if y, ok := x.(Trigger); ok && isBuiltin(y) {
+			t.Error("built-in Triggers added to invalid declaration")
+			break
+		}
+	}
+	tv = Tri{"pod", Brief{"brief"}, Version{0, 1, 1},
+		Var{"port", Brief{"brief"}, Default{1}, Slot{&port}},
+	}
+	if e = tv.ValidateAll(); e != nil || tv.dataDirVar() == nil {
+		t.Error("validator rejected valid declaration or did not add built-in items:", e)
+	}
+}
+
 func TestTrigger(t *testing.T) {
 	// contains at least 3 elements
 	tt1 := Trigger{1, 1}
@@ -734,6 +1226,20 @@ func TestTrigger(t *testing.T) {
 	}
 }
 
+func TestURL(t *testing.T) {
+	for i, x := range []URL{{1}, {""}, {"1http"}, {"http:"}, {"socks5", "http", "HTTP"}} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid URL %d", i)
+		}
+	}
+	// no error
+	for i, x := range []URL{{}, {"http", "https"}, {"svn+ssh", "socks5"}} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid URL %d: %v", i, e)
+		}
+	}
+}
+
 func TestUsage(t *testing.T) {
 
 	// only one element
@@ -809,6 +1315,21 @@ func TestValidName(t *testing.T) {
 
 }
 
+func TestVariadic(t *testing.T) {
+
+	// may not contain anything
+	tv1 := Variadic{""}
+	if e := tv1.Validate(); e == nil {
+		t.Error("validator accepted content in Variadic")
+	}
+	// no error
+	tv2 := Variadic{}
+	if e := tv2.Validate(); e != nil {
+		t.Error("validator rejected valid Variadic")
+	}
+
+}
+
 func TestVar(t *testing.T) {
 	// contains at least 3 elements
 	tv1 := Var{1, 1}
@@ -924,10 +1445,50 @@ func TestVar(t *testing.T) {
 		t.Error("validator allowed default that can't be assigned to Slot")
 	}
 	var tfloat64 float64
-	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Default{5}}
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Default{"5"}}
 	if e := tv20.Validate(); e == nil {
 		t.Error("validator allowed default that can't be assigned to Slot")
 	}
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tint}, Default{2.5}}
+	if e := tv20.Validate(); e == nil {
+		t.Error("validator allowed default that changes when converted to Slot type")
+	}
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Default{-1}}
+	if e := tv20.Validate(); e == nil {
+		t.Error("validator allowed default that changes when converted to Slot type")
+	}
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Default{nil}}
+	if e := tv20.Validate(); e == nil {
+		t.Error("validator allowed nil default for Slot type that cannot be nil")
+	}
+	// numbers that convert exactly, and values of the same kind as a named Slot type, are converted
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Default{5}}
+	if e := tv20.Validate(); e != nil || defaultValue(tv20) != 5.0 {
+		t.Error("validator rejected default convertible to Slot type", e)
+	}
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Default{1 << 20}}
+	if e := tv20.Validate(); e != nil {
+		t.Error("validator rejected default convertible to Slot type", e)
+	}
+	if found, e := LoadDefaults(&tv20); !found || e != nil || tuint32 != 1<<20 {
+		t.Error("default convertible to Slot type was not loaded", e)
+	}
+	type network string
+	var tnetwork network
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tnetwork}, Default{"mainnet"},
+		Handler{func(s string) (interface{}, error) { return network(s), nil }},
+	}
+	if e := tv20.Validate(); e != nil {
+		t.Error("validator rejected default convertible to handler-provided Slot type", e)
+	}
+	if found, e := LoadDefaults(&tv20); !found || e != nil || tnetwork != "mainnet" {
+		t.Error("default convertible to handler-provided Slot type was not loaded", e)
+	}
+	var tlist []string
+	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tlist}, Default{nil}}
+	if e := tv20.Validate(); e != nil {
+		t.Error("validator rejected nil default for slice Slot", e)
+	}
 	var tstringslice []string
 	tv20 = Var{"aaaa", Brief{"aaaa"}, Slot{&tstringslice}, Default{"aaa"}}
 	if e := tv20.Validate(); e == nil {
@@ -943,6 +1504,168 @@ func TestVar(t *testing.T) {
 	if e := tv21.Validate(); e != nil {
 		t.Error("validator rejected valid Var")
 	}
+	// has only one Handler
+	validate := func(v interface{}) error {
+		if v.(string) == "" {
+			return errors.New("empty")
+		}
+		return nil
+	}
+	tv22 := Var{"aaaa", Brief{"aaaa"}, Slot{&tstring},
+		Handler{validate}, Handler{validate},
+	}
+	if e := tv22.Validate(); e == nil {
+		t.Error("validator accepted more than one Handler")
+	}
+	// Default passes the Handler's validate function
+	tv23 := Var{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Default{""}, Handler{validate}}
+	if e := tv23.Validate(); e == nil {
+		t.Error("validator accepted Default rejected by the Handler")
+	}
+	// Slot of a type without a built-in parser needs a Handler that parses it
+	var tbytes []byte
+	tv24 := Var{"aaaa", Brief{"aaaa"}, Slot{&tbytes}}
+	if e := tv24.Validate(); e == nil {
+		t.Error("validator accepted Slot type that cannot be parsed")
+	}
+	parse := func(s string) (interface{}, error) { return []byte(s), nil }
+	tv24 = Var{"aaaa", Brief{"aaaa"}, Slot{&tbytes}, Default{"aaa"}, Handler{parse}}
+	if e := tv24.Validate(); e == nil {
+		t.Error("validator allowed default that can't be assigned to Slot")
+	}
+	tv24 = Var{"aaaa", Brief{"aaaa"}, Slot{&tbytes}, Default{[]byte("aaa")}, Handler{parse}}
+	if e := tv24.Validate(); e != nil {
+		t.Error("validator rejected Var with Handler for its Slot type:", e)
+	}
+	// Precision only in float64 Vars, and the Default does not exceed it
+	tv25 := Var{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Precision{2}}
+	if e := tv25.Validate(); e == nil {
+		t.Error("validator accepted Precision in Var that is not float64")
+	}
+	tv25 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Precision{2}, Precision{2}}
+	if e := tv25.Validate(); e == nil {
+		t.Error("validator accepted more than one Precision")
+	}
+	tv25 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Precision{2}, Default{0.125}}
+	if e := tv25.Validate(); e == nil {
+		t.Error("validator accepted Default with more decimal places than Precision")
+	}
+	tv25 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Default{0.000000001}}
+	if e := tv25.Validate(); e == nil {
+		t.Error("validator accepted Default with more than 8 decimal places")
+	}
+	tv25 = Var{"aaaa", Brief{"aaaa"}, Slot{&tfloat64}, Precision{-1}, Default{0.000000001}}
+	if e := tv25.Validate(); e != nil {
+		t.Error("validator rejected valid Var with Precision:", e)
+	}
+	// Required may not be used with a Default, nor more than once
+	tv26 := Var{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Required{}, Default{"aaaa"}}
+	if e := tv26.Validate(); e == nil || !strings.Contains(e.Error(), "Required") {
+		t.Error("validator accepted Required Var with a Default")
+	}
+	tv26 = Var{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Required{}, Required{}}
+	if e := tv26.Validate(); e == nil {
+		t.Error("validator accepted more than one Required")
+	}
+	tv26 = Var{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Required{}}
+	if e := tv26.Validate(); e != nil {
+		t.Error("validator rejected valid Required Var:", e)
+	}
+	// Allowed values must be of the type of the Slot, or its items, and the Default must be one of them
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Allowed{"aaaa", 1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Allowed{0.5}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Allowed{"aaaa"}, Allowed{"bbbb"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Allowed{"aaaa", "bbbb"}, Default{"cccc"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tlist}, Allowed{"aaaa", "bbbb"}, Default{[]string{"aaaa", "cccc"}}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Allowed in Var %d", i)
+		}
+	}
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Allowed{"aaaa", "bbbb"}, Default{"bbbb"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Allowed{-1, 1, 2}, Default{-1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tlist}, Allowed{"aaaa", "bbbb"}, Default{[]string{"bbbb", "aaaa"}}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Required{}, Allowed{"aaaa"}},
+	} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid Allowed in Var %d: %v", i, e)
+		}
+	}
+	// Min, Max and Sentinels are for integer Slots that can hold them, and the Default must be in range or a Sentinel
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Min{1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tduration}, Max{1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Sentinels{-1, "all"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Min{-1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Sentinels{-1, "all"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Min{1}, Min{2}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Min{5}, Max{4}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Min{1}, Max{4}, Default{5}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Min{1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Min{1}, Sentinels{-1, "all"}, Default{-2}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid range in Var %d", i)
+		}
+	}
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Min{1}, Max{4}, Default{4}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Min{1}, Sentinels{-1, "all"}, Default{-1}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Min{1}, Sentinels{0, "off"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Max{1 << 20}, Default{1024}},
+	} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid range in Var %d: %v", i, e)
+		}
+	}
+	// Address and Port need a Slot that can hold them, and a valid Default
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Address{}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tuint32}, Port{}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Address{}, Port{}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Address{}, Default{"localhost"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tlist}, Address{11048}, Default{[]string{"a:b:c"}}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Port{}, Default{"70000"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Port{}, Default{0}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid Address or Port in Var %d", i)
+		}
+	}
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Address{11048}, Default{"localhost"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tlist}, Address{}, Default{[]string{"[::1]:80"}}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Port{}, Default{"11048"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, Port{}, Default{11048}},
+	} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid Address or Port in Var %d: %v", i, e)
+		}
+	}
+	// URL needs a Slot that can hold them, Host needs URL, and the Default must be a URL that they allow
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tint}, URL{}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, URL{}, Address{}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, Host{}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, URL{"http"}, Default{"ftp://host"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, URL{}, Default{"/path"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tlist}, URL{}, Host{}, Default{[]string{"http://a", "file:///path"}}},
+	} {
+		if e := x.Validate(); e == nil {
+			t.Errorf("validator accepted invalid URL in Var %d", i)
+		}
+	}
+	for i, x := range []Var{
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, URL{"http", "https"}, Host{}, Default{"http://127.0.0.1:11048"}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tlist}, URL{}, Default{[]string{"file:///path", "HTTP://a"}}},
+		{"aaaa", Brief{"aaaa"}, Slot{&tstring}, URL{"socks5"}, Host{}},
+	} {
+		if e := x.Validate(); e != nil {
+			t.Errorf("validator rejected valid URL in Var %d: %v", i, e)
+		}
+	}
 
 }
 